@@ -0,0 +1,182 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "os"
+    "slices"
+    "sync"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+)
+
+// defaultJobs 是批量操作的默认并发数
+const defaultJobs = 4
+
+// entryLog 记录单个条目的输出
+// 并行执行时输出先缓存，完成后按条目顺序打印，避免多个条目的输出交错
+type entryLog struct {
+    direct bool
+    stderr bool // 所有输出写到标准错误，让标准输出只包含其他程序的输出
+    lines  []logLine
+}
+
+// logLine 是一行缓存的输出
+type logLine struct {
+    stderr bool
+    text   string
+}
+
+// Printf 输出到标准输出
+func (l *entryLog) Printf(format string, a ...any) {
+    l.write(false, fmt.Sprintf(format, a...))
+}
+
+// Errorf 输出到标准错误
+func (l *entryLog) Errorf(format string, a ...any) {
+    l.write(true, fmt.Sprintf(format, a...))
+}
+
+func (l *entryLog) write(stderr bool, text string) {
+    stderr = stderr || l.stderr
+    if l.direct {
+        fmt.Fprint(streamFor(stderr), text)
+        return
+    }
+    l.lines = append(l.lines, logLine{stderr: stderr, text: text})
+}
+
+// flush 打印缓存的输出
+func (l *entryLog) flush() {
+    for _, line := range l.lines {
+        fmt.Fprint(streamFor(line.stderr), line.text)
+    }
+    l.lines = nil
+}
+
+func streamFor(stderr bool) io.Writer {
+    if stderr {
+        return os.Stderr
+    }
+    return os.Stdout
+}
+
+// runBatch 以最多 jobs 个并发对每个条目执行 fn，返回成功和失败的数量
+// 每个条目的输出以 "[i/n] name" 开头，按条目顺序打印；fn 返回错误时记为失败
+func runBatch(entries []*config.MountEntry, jobs int, fn func(entry *config.MountEntry, log *entryLog) error) (succeeded, failed int) {
+    return runBatchAfter(entries, jobs, nil, fn)
+}
+
+// runBatchAfter 与 runBatch 相同，但条目要等 after 中列出的条目完成后才执行，其中任一失败时跳过该条目
+// after 中的条目必须排在 entries 中更靠前的位置
+func runBatchAfter(entries []*config.MountEntry, jobs int, after map[*config.MountEntry][]*config.MountEntry, fn func(entry *config.MountEntry, log *entryLog) error) (succeeded, failed int) {
+    if jobs < 1 {
+        jobs = 1
+    }
+    direct := jobs == 1 || len(entries) == 1
+
+    logs := make([]*entryLog, len(entries))
+    errs := make([]error, len(entries))
+    done := make(chan int)
+
+    index := make(map[*config.MountEntry]int, len(entries))
+    completed := make([]chan struct{}, len(entries))
+    for i, entry := range entries {
+        index[entry] = i
+        completed[i] = make(chan struct{})
+    }
+
+    go func() {
+        sem := make(chan struct{}, jobs)
+        var wg sync.WaitGroup
+        for i, entry := range entries {
+            sem <- struct{}{}
+            wg.Add(1)
+            go func(i int, entry *config.MountEntry) {
+                defer func() {
+                    close(completed[i])
+                    <-sem
+                    wg.Done()
+                }()
+
+                log := &entryLog{direct: direct}
+                log.Printf("[%d/%d] %s\n", i+1, len(entries), entry.Name)
+                if err := waitFor(after[entry], index, completed, errs); err != nil {
+                    log.Errorf("  Skipped: %v\n", err)
+                    errs[i] = err
+                } else if err := fn(entry, log); err != nil {
+                    log.Errorf("  Failed: %v\n", err)
+                    errs[i] = err
+                }
+                log.Printf("\n")
+                logs[i] = log
+                done <- i
+            }(i, entry)
+
+            // In direct mode entries run one by one so their output is not interleaved
+            if direct {
+                wg.Wait()
+            }
+        }
+        wg.Wait()
+        close(done)
+    }()
+
+    // Print finished entries in order
+    finished := make([]bool, len(entries))
+    next := 0
+    for i := range done {
+        finished[i] = true
+        for next < len(entries) && finished[next] {
+            logs[next].flush()
+            if errs[next] != nil {
+                failed++
+            } else {
+                succeeded++
+            }
+            next++
+        }
+    }
+
+    return succeeded, failed
+}
+
+// waitFor 等待 deps 中的条目完成，其中有条目失败时返回错误
+func waitFor(deps []*config.MountEntry, index map[*config.MountEntry]int, completed []chan struct{}, errs []error) error {
+    for _, dep := range deps {
+        j := index[dep]
+        <-completed[j]
+        if errs[j] != nil {
+            return fmt.Errorf("%s failed", dep.Name)
+        }
+    }
+    return nil
+}
+
+// batchOrder 按依赖关系排列批量操作的条目，并返回每个条目需要等待的条目
+// 挂载时前置条目先执行；卸载时 reverse 为 true，依赖它的条目先卸载
+func batchOrder(cfg *config.Config, entries []*config.MountEntry, reverse bool) ([]*config.MountEntry, map[*config.MountEntry][]*config.MountEntry, error) {
+    ordered, err := cfg.MountOrder(entries)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    after := make(map[*config.MountEntry][]*config.MountEntry)
+    for _, entry := range ordered {
+        for _, dep := range cfg.Dependencies(entry) {
+            if !slices.Contains(ordered, dep) {
+                continue
+            }
+            if reverse {
+                after[dep] = append(after[dep], entry)
+            } else {
+                after[entry] = append(after[entry], dep)
+            }
+        }
+    }
+
+    if reverse {
+        slices.Reverse(ordered)
+    }
+    return ordered, after, nil
+}
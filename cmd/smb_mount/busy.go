@@ -0,0 +1,89 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "slices"
+    "sync"
+    "syscall"
+    "time"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/hsldymq/smb_mount/internal/interaction"
+    "github.com/hsldymq/smb_mount/internal/mount"
+)
+
+// signalWait 是向占用进程发送 SIGTERM 后，重试卸载前的等待时间
+const signalWait = time.Second
+
+// busyMounts 收集因挂载点被占用而卸载失败的条目以及占用它们的进程
+// 并行卸载时会被多个 goroutine 同时调用
+type busyMounts struct {
+    mu      sync.Mutex
+    entries []*config.MountEntry
+    pids    []int
+}
+
+// report 列出占用条目挂载点的进程并记录下来
+func (b *busyMounts) report(entry *config.MountEntry, log *entryLog) {
+    holders, err := mount.FindHolders(entry.ActualMountPath)
+    if err != nil {
+        log.Errorf("  Target is busy (failed to find the processes using it: %v)\n", err)
+        return
+    }
+
+    if len(holders) == 0 {
+        log.Errorf("  Target is busy, but no process using it was found\n")
+        if interaction.NeedsPrivilege() {
+            log.Errorf("  Processes of other users are only visible to root\n")
+        }
+    } else {
+        log.Errorf("  Target is busy, in use by:\n")
+        for _, h := range holders {
+            log.Errorf("    PID %-7d %-15s %s (%s)\n", h.PID, h.Command, h.Path, h.Use)
+        }
+    }
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.entries = append(b.entries, entry)
+    for _, h := range holders {
+        if !slices.Contains(b.pids, h.PID) {
+            b.pids = append(b.pids, h.PID)
+        }
+    }
+}
+
+// offerSignal 在终端中询问是否向占用进程发送 SIGTERM
+// 用户同意且信号发送成功时返回需要重试卸载的条目
+func (b *busyMounts) offerSignal() []*config.MountEntry {
+    if len(b.entries) == 0 {
+        return nil
+    }
+    if len(b.pids) == 0 || !interaction.IsTerminal() {
+        fmt.Println("Hint: use --lazy to detach busy mounts now and finish unmounting once they are no longer in use")
+        fmt.Println()
+        return nil
+    }
+
+    ok, err := interaction.Confirm(fmt.Sprintf("Send SIGTERM to %d process(es) and retry?", len(b.pids)))
+    fmt.Println()
+    if err != nil || !ok {
+        return nil
+    }
+
+    signalled := 0
+    for _, pid := range b.pids {
+        if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+            fmt.Fprintf(os.Stderr, "Failed to signal PID %d: %v\n", pid, err)
+            continue
+        }
+        signalled++
+    }
+    if signalled == 0 {
+        return nil
+    }
+
+    time.Sleep(signalWait)
+    return b.entries
+}
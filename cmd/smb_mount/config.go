@@ -0,0 +1,173 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "os"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+    Use:   "config",
+    Short: "管理配置文件",
+    Long:  `检查和管理 smb_mount 的配置文件。`,
+}
+
+var configValidateCmd = &cobra.Command{
+    Use:   "validate",
+    Short: "验证配置文件",
+    Long: `加载并验证配置文件，报告所有问题及其所在的行号和列号。
+检查内容包括必填字段、未知键、重复的名称、重复的挂载路径、未知或循环的依赖以及无效的主机名。
+嵌套的挂载路径不是错误，外层共享会作为依赖先挂载；由嵌套路径形成的循环同样会报告。`,
+    Args: cobra.NoArgs,
+    RunE: runConfigValidate,
+}
+
+var configSchemaCmd = &cobra.Command{
+    Use:   "schema",
+    Short: "输出配置文件的 JSON Schema",
+    Long: `根据配置结构生成 JSON Schema，可用于编辑器的自动补全和实时校验。
+默认输出到标准输出，使用 --output 写入文件。`,
+    Args: cobra.NoArgs,
+    RunE: runConfigSchema,
+}
+
+var configInitCmd = &cobra.Command{
+    Use:   "init",
+    Short: "创建初始配置文件",
+    Long: `在配置路径创建初始配置文件，并在其旁边写入 JSON Schema。
+生成的配置文件包含 yaml-language-server 注释，编辑器会自动关联该 schema。`,
+    Args: cobra.NoArgs,
+    RunE: runConfigInit,
+}
+
+var configMigrateCmd = &cobra.Command{
+    Use:   "migrate",
+    Short: "将配置文件升级到当前格式版本",
+    Long: `显示将旧版本配置文件升级到当前格式版本所需的修改。
+使用 --write 将修改写入文件，原文件会先备份。`,
+    Args: cobra.NoArgs,
+    RunE: runConfigMigrate,
+}
+
+var (
+    schemaOutput string
+    initForce    bool
+    migrateWrite bool
+)
+
+func init() {
+    configSchemaCmd.Flags().StringVarP(&schemaOutput, "output", "o", "", "schema 输出文件路径")
+    configInitCmd.Flags().BoolVarP(&initForce, "force", "f", false, "覆盖已存在的配置文件")
+    configMigrateCmd.Flags().BoolVarP(&migrateWrite, "write", "w", false, "备份原文件并写入升级后的配置")
+
+    configCmd.AddCommand(configValidateCmd)
+    configCmd.AddCommand(configSchemaCmd)
+    configCmd.AddCommand(configInitCmd)
+    configCmd.AddCommand(configMigrateCmd)
+    rootCmd.AddCommand(configCmd)
+}
+
+// resolveConfigPath 返回指定的或默认的配置文件路径
+func resolveConfigPath() string {
+    if configPath != "" {
+        return configPath
+    }
+    return config.DefaultConfigPath()
+}
+
+// runConfigValidate 实现配置验证命令
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+    path := resolveConfigPath()
+
+    cfg, err := config.LoadWithOptions(path, config.LoadOptions{StrictEnv: strictEnv})
+    if err != nil {
+        var verr *config.ValidationError
+        if !errors.As(err, &verr) {
+            return err
+        }
+        for _, issue := range verr.Issues {
+            fmt.Fprintf(os.Stderr, "%s: error: %s\n", issue.Location(verr.File), issue)
+        }
+        return fmt.Errorf("%s: %d problem(s) found", path, len(verr.Issues))
+    }
+
+    warnings, _ := config.CheckConfigPermissions(path)
+    for _, w := range warnings {
+        fmt.Fprintf(os.Stderr, "%s: warning: %s\n", path, w)
+    }
+
+    if cfg.SourceVersion < config.CurrentVersion {
+        fmt.Fprintf(os.Stderr, "%s: warning: format version %d is outdated, run 'smb_mount config migrate' to upgrade\n", path, cfg.SourceVersion)
+    }
+
+    fmt.Printf("%s: OK (%d mount entries)\n", path, len(cfg.Mounts))
+    return nil
+}
+
+// runConfigSchema 实现 schema 输出命令
+func runConfigSchema(cmd *cobra.Command, args []string) error {
+    schema, err := config.SchemaJSON()
+    if err != nil {
+        return err
+    }
+
+    if schemaOutput == "" {
+        _, err := os.Stdout.Write(schema)
+        return err
+    }
+
+    if err := os.WriteFile(schemaOutput, schema, 0644); err != nil {
+        return fmt.Errorf("failed to write schema: %w", err)
+    }
+    fmt.Printf("Schema written to %s\n", schemaOutput)
+    return nil
+}
+
+// runConfigInit 实现配置初始化命令
+func runConfigInit(cmd *cobra.Command, args []string) error {
+    path := resolveConfigPath()
+
+    schemaPath, err := config.WriteTemplate(path, initForce)
+    if err != nil {
+        return err
+    }
+
+    fmt.Printf("Config written to %s\n", path)
+    fmt.Printf("Schema written to %s\n", schemaPath)
+    return nil
+}
+
+// runConfigMigrate 实现配置迁移命令
+func runConfigMigrate(cmd *cobra.Command, args []string) error {
+    path := resolveConfigPath()
+
+    result, err := config.MigrateFile(path, migrateWrite)
+    if err != nil {
+        return err
+    }
+
+    if len(result.Applied) == 0 {
+        fmt.Printf("%s is already at version %d\n", path, config.CurrentVersion)
+        return nil
+    }
+
+    fmt.Printf("Migrating %s from version %d to %d:\n", path, result.FromVersion, config.CurrentVersion)
+    for _, m := range result.Applied {
+        fmt.Printf("  v%d -> v%d: %s\n", m.From, m.From+1, m.Description)
+    }
+    fmt.Println()
+    fmt.Print(result.Diff(path))
+    fmt.Println()
+
+    if !migrateWrite {
+        fmt.Println("Run with --write to apply these changes")
+        return nil
+    }
+
+    fmt.Printf("Backup written to %s\n", result.BackupPath)
+    fmt.Printf("Config migrated to version %d\n", config.CurrentVersion)
+    return nil
+}
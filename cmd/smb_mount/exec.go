@@ -0,0 +1,193 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "os/exec"
+    "os/signal"
+    "slices"
+    "strings"
+    "syscall"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/hsldymq/smb_mount/internal/mount"
+    "github.com/spf13/cobra"
+)
+
+// forwardedSignals 是转发给被执行命令的信号
+var forwardedSignals = []os.Signal{syscall.SIGTERM, syscall.SIGHUP}
+
+// terminalSignals 是终端发给整个前台进程组的信号，被执行的命令已经直接收到，只需让 smb_mount 不受影响
+var terminalSignals = []os.Signal{syscall.SIGINT, syscall.SIGQUIT}
+
+var execCmd = &cobra.Command{
+    Use:   "exec <name|@tag|pattern...> -- <command> [args...]",
+    Short: "挂载共享后执行命令，结束后卸载",
+    Long: `挂载指定的共享（已挂载的保持不变）后执行命令，命令结束后只卸载本次挂载的共享。
+每个共享的挂载路径通过环境变量 SMB_MOUNT_<NAME>_PATH 传给命令，
+NAME 为条目名称的大写形式，字母和数字以外的字符替换为下划线。
+收到的信号会转发给命令，smb_mount 以命令的退出码退出。
+smb_mount 自身的输出写到标准错误，标准输出只包含命令的输出。`,
+    Args: func(cmd *cobra.Command, args []string) error {
+        dash := cmd.ArgsLenAtDash()
+        if dash < 1 || dash == len(args) {
+            return fmt.Errorf("requires shares before -- and a command after it")
+        }
+        return nil
+    },
+    RunE: runExec,
+}
+
+// exitCodeError 使 smb_mount 以指定的退出码退出，不再打印错误
+type exitCodeError struct {
+    code int
+}
+
+func (e *exitCodeError) Error() string {
+    return fmt.Sprintf("exit status %d", e.code)
+}
+
+// runExec 实现 exec 命令
+func runExec(cmd *cobra.Command, args []string) error {
+    dash := cmd.ArgsLenAtDash()
+    selectors, command := args[:dash], args[dash:]
+
+    cfg, err := loadConfig()
+    if err != nil {
+        return err
+    }
+    if err := configureBackend(cfg); err != nil {
+        return err
+    }
+    if _, err := mount.RefreshAllStatus(cfg); err != nil {
+        fmt.Fprintf(os.Stderr, "Warning: failed to refresh mount status: %v\n", err)
+    }
+
+    entries, err := cfg.Select(selectors)
+    if err != nil {
+        return err
+    }
+    entries, _, err = batchOrder(cfg, entries, false)
+    if err != nil {
+        return err
+    }
+
+    // 命令结束或被信号中断后仍然要卸载，卸载不受 ctx 取消的影响
+    ctx := cmd.Context()
+    log := &entryLog{direct: true, stderr: true}
+    var mounted []*config.MountEntry
+    unmount := func() error {
+        var errs []error
+        for _, entry := range slices.Backward(mounted) {
+            log.Printf("Unmounting %s\n", entry.Name)
+            if err := umountEntry(context.WithoutCancel(ctx), cfg, entry, mount.UnmountOptions{}, log); err != nil {
+                errs = append(errs, fmt.Errorf("failed to unmount %s: %w", entry.Name, err))
+            }
+        }
+        return errors.Join(errs...)
+    }
+
+    for _, entry := range entries {
+        if entry.IsMounted {
+            continue
+        }
+        log.Printf("Mounting %s\n", entry.Name)
+        err := checkPrerequisites(cfg, entry, entries)
+        if err == nil {
+            err = prepareMountEntry(entry, log)
+        }
+        if err == nil {
+            err = mountEntry(ctx, cfg, entry, log)
+        }
+        if err != nil {
+            return errors.Join(fmt.Errorf("failed to mount %s: %w", entry.Name, err), unmount())
+        }
+        mounted = append(mounted, entry)
+    }
+
+    c := exec.Command(command[0], command[1:]...)
+    c.Env = os.Environ()
+    for _, entry := range entries {
+        c.Env = append(c.Env, pathEnvName(entry.Name)+"="+entry.ActualMountPath)
+    }
+    code, runErr := runCommand(c)
+    if err := unmount(); err != nil {
+        if runErr != nil {
+            return errors.Join(runErr, err)
+        }
+        fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+        code = max(code, 1)
+    }
+    if runErr != nil {
+        return runErr
+    }
+
+    if code != 0 {
+        cmd.SilenceErrors = true
+        cmd.SilenceUsage = true
+        return &exitCodeError{code: code}
+    }
+    return nil
+}
+
+// runCommand 在当前终端中执行命令，返回命令的退出码
+// 命令运行期间转发 SIGTERM 和 SIGHUP；Ctrl-C 等终端信号不转发，避免命令收到两次
+// 命令被信号终止时按 shell 的惯例返回 128 加信号编号
+func runCommand(c *exec.Cmd) (int, error) {
+    c.Stdin = os.Stdin
+    c.Stdout = os.Stdout
+    c.Stderr = os.Stderr
+
+    sigs := make(chan os.Signal, 1)
+    signal.Notify(sigs, forwardedSignals...)
+    defer signal.Stop(sigs)
+
+    // Catch rather than ignore, an ignored signal would stay ignored in the command
+    ignored := make(chan os.Signal, 1)
+    signal.Notify(ignored, terminalSignals...)
+    defer signal.Stop(ignored)
+
+    if err := c.Start(); err != nil {
+        return 0, fmt.Errorf("failed to run %s: %w", c.Args[0], err)
+    }
+    done := make(chan struct{})
+    go func() {
+        for {
+            select {
+            case sig := <-sigs:
+                _ = c.Process.Signal(sig)
+            case <-ignored:
+            case <-done:
+                return
+            }
+        }
+    }()
+    err := c.Wait()
+    close(done)
+
+    var exitErr *exec.ExitError
+    if errors.As(err, &exitErr) {
+        if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+            return 128 + int(status.Signal()), nil
+        }
+        return exitErr.ExitCode(), nil
+    }
+    return 0, err
+}
+
+// pathEnvName 返回传递条目挂载路径的环境变量名，例如 media-server 对应 SMB_MOUNT_MEDIA_SERVER_PATH
+func pathEnvName(name string) string {
+    upper := strings.Map(func(r rune) rune {
+        switch {
+        case r >= 'a' && r <= 'z':
+            return r - 'a' + 'A'
+        case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+            return r
+        default:
+            return '_'
+        }
+    }, name)
+    return "SMB_MOUNT_" + upper + "_PATH"
+}
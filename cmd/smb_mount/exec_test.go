@@ -0,0 +1,53 @@
+package main
+
+import (
+    "os"
+    "os/exec"
+    "path/filepath"
+    "syscall"
+    "testing"
+    "time"
+)
+
+// 终端信号由终端直接发给命令，runCommand 只转发 SIGTERM 和 SIGHUP
+func TestRunCommandSignals(t *testing.T) {
+    dir := t.TempDir()
+    ready, got := filepath.Join(dir, "ready"), filepath.Join(dir, "signals")
+    c := exec.Command("sh", "-c", `trap 'echo INT >> "$2"' INT; trap 'echo TERM >> "$2"; exit 3' TERM; : > "$1"; while :; do sleep 0.05; done`, "sh", ready, got)
+
+    type result struct {
+        code int
+        err  error
+    }
+    done := make(chan result, 1)
+    go func() {
+        code, err := runCommand(c)
+        done <- result{code, err}
+    }()
+
+    for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+        if _, err := os.Stat(ready); err == nil {
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatal("command did not start")
+        }
+    }
+
+    _ = syscall.Kill(os.Getpid(), syscall.SIGINT)
+    time.Sleep(200 * time.Millisecond)
+    _ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+
+    select {
+    case r := <-done:
+        if r.err != nil || r.code != 3 {
+            t.Errorf("runCommand() = %d, %v, want 3", r.code, r.err)
+        }
+    case <-time.After(5 * time.Second):
+        _ = c.Process.Kill()
+        t.Fatal("SIGTERM was not forwarded")
+    }
+    if data, _ := os.ReadFile(got); string(data) != "TERM\n" {
+        t.Errorf("command received %q, want only TERM", data)
+    }
+}
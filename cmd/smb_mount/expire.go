@@ -0,0 +1,271 @@
+package main
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strings"
+    "sync"
+    "syscall"
+    "time"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/hsldymq/smb_mount/internal/interaction"
+    "github.com/hsldymq/smb_mount/internal/mount"
+    "github.com/hsldymq/smb_mount/internal/state"
+    "github.com/spf13/cobra"
+)
+
+// expireCommand 是在限时挂载到期时执行卸载的内部命令，由挂载流程在后台启动
+const expireCommand = "__expire"
+
+var expireCmd = &cobra.Command{
+    Use:    expireCommand + " <mount path>",
+    Hidden: true,
+    Args:   cobra.ExactArgs(1),
+    RunE:   runExpire,
+}
+
+// checkUnattendedUnmount 检查到期进程和 reaper 能否在无人值守时卸载共享，不能时返回原因
+// 它们没有终端，sudo 无法询问密码，也不能使用终端中缓存的凭据
+// 只检查一次，批量挂载的所有条目共用结果
+var checkUnattendedUnmount = sync.OnceValue(func() error {
+    if !interaction.NeedsPrivilege() {
+        return nil
+    }
+    if name := mount.Default().Name(); name != mount.BackendExec {
+        return fmt.Errorf("the automatic unmount needs root with the %s backend, run smb_mount as root to unmount shares automatically", name)
+    }
+    if !interaction.CanSudoWithoutPassword(exec.Command("umount", "--version")) {
+        return fmt.Errorf("the automatic unmount runs without a terminal and cannot enter a sudo password, allow umount without a password in sudoers or run smb_mount as root")
+    }
+    return nil
+})
+
+// scheduleUnmount 记录挂载的到期时间，并启动到期时卸载的后台进程
+// 后台进程在独立会话中运行，smb_mount 退出后仍然保持；已有的到期进程会被替换
+func scheduleUnmount(entry *config.MountEntry, d time.Duration, log *entryLog) error {
+    exe, err := os.Executable()
+    if err != nil {
+        return fmt.Errorf("failed to locate smb_mount executable: %w", err)
+    }
+    configFile, err := filepath.Abs(resolveConfigPath())
+    if err != nil {
+        return fmt.Errorf("failed to resolve config path: %w", err)
+    }
+
+    logFile, err := state.OpenLog("expire-"+strings.ReplaceAll(entry.Name, "/", "_")+".log", os.O_APPEND)
+    if err != nil {
+        return fmt.Errorf("failed to open expiry log: %w", err)
+    }
+    defer logFile.Close()
+
+    args := []string{expireCommand, "--config", configFile}
+    if backend != "" {
+        args = append(args, "--backend", backend)
+    }
+    args = append(args, "--", entry.ActualMountPath)
+    cmd := exec.Command(exe, args...)
+    cmd.Stdout = logFile
+    cmd.Stderr = logFile
+    cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+    at := time.Now().Add(d)
+    // The process is started while the state is locked, so it finds its record once it reads the state
+    err = state.Update(func(s *state.State) error {
+        m := s.Ensure(entry.ActualMountPath, entry.Name)
+        if m.Expiry != nil {
+            stopExpiryProcess(m.Expiry.PID)
+        }
+        if err := cmd.Start(); err != nil {
+            return fmt.Errorf("failed to start expiry process: %w", err)
+        }
+        m.Expiry = &state.Expiry{At: at, PID: cmd.Process.Pid}
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    _ = cmd.Process.Release()
+
+    entry.ExpiresAt = at
+    log.Printf("  Unmounting automatically at %s (in %s)\n", at.Format("15:04"), d)
+    return nil
+}
+
+// stopExpiry 停止为挂载点启动的到期进程并删除到期时间
+func stopExpiry(entry *config.MountEntry) error {
+    return state.Update(func(s *state.State) error {
+        m := s.Get(entry.ActualMountPath)
+        if m == nil || m.Expiry == nil {
+            return nil
+        }
+        stopExpiryProcess(m.Expiry.PID)
+        m.Expiry = nil
+        return nil
+    })
+}
+
+// stopExpiryProcess 结束到期进程，到期进程自己执行卸载时不处理
+func stopExpiryProcess(pid int) {
+    if pid != os.Getpid() && expiryRunning(pid) {
+        _ = syscall.Kill(pid, syscall.SIGTERM)
+    }
+}
+
+// expiryRunning 返回 pid 是否为正在运行的到期进程
+func expiryRunning(pid int) bool {
+    if pid <= 0 {
+        return false
+    }
+    cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+    if err != nil {
+        return false
+    }
+    args := bytes.Split(cmdline, []byte{0})
+    return len(args) >= 2 && string(args[1]) == expireCommand
+}
+
+// loadExpiry 从状态文件读取已挂载条目的到期时间，并检查到期进程是否仍在运行
+func loadExpiry(cfg *config.Config) error {
+    s, err := state.Load()
+    if err != nil {
+        return err
+    }
+    for i := range cfg.Mounts {
+        entry := &cfg.Mounts[i]
+        if m := s.Get(entry.ActualMountPath); entry.IsMounted && m != nil && m.Expiry != nil {
+            entry.ExpiresAt = m.Expiry.At
+            entry.ExpiryStopped = !expiryRunning(m.Expiry.PID)
+        }
+    }
+    return nil
+}
+
+// 到期后卸载失败时的重试间隔，每次失败后加倍
+const (
+    expireRetryInitial = 10 * time.Second
+    expireRetryMax     = 5 * time.Minute
+)
+
+// expireLazyAfter 是到期后挂载点持续被占用多久后改用延迟卸载
+const expireLazyAfter = 15 * time.Minute
+
+// expirePoll 是等待到期时检查时间的最长间隔
+// 计时器使用的单调时钟在系统休眠时停止，分段等待并比较挂钟时间，休眠跨过到期时间时唤醒后即可卸载
+const expirePoll = 30 * time.Second
+
+// runExpire 等待挂载到期后将其卸载
+// 到期时间在等待期间被延长时继续等待；记录被删除或由其他进程接管时退出
+// 卸载失败时按退避间隔重试，记录保留到共享真正卸载；挂载点持续被占用时改用延迟卸载
+func runExpire(cmd *cobra.Command, args []string) error {
+    mountPath := args[0]
+    ctx := cmd.Context()
+
+    for {
+        expiry, err := ownExpiry(mountPath)
+        if err != nil || expiry == nil {
+            return err
+        }
+        // The time read from the state file has no monotonic reading, so this compares wall clock times
+        wait := time.Until(expiry.At)
+        if wait <= 0 {
+            break
+        }
+        if !sleep(ctx, min(wait, expirePoll)) {
+            return nil
+        }
+    }
+
+    cfg, err := loadConfig()
+    if err != nil {
+        return err
+    }
+    if err := configureBackend(cfg); err != nil {
+        return err
+    }
+
+    log := &entryLog{direct: true}
+    delay := expireRetryInitial
+    var busySince time.Time
+    for attempt := 1; ; attempt++ {
+        if _, err := mount.RefreshAllStatus(cfg); err != nil {
+            return err
+        }
+        var entry *config.MountEntry
+        for i := range cfg.Mounts {
+            if cfg.Mounts[i].ActualMountPath == mountPath {
+                entry = &cfg.Mounts[i]
+            }
+        }
+        if entry == nil || !entry.IsMounted {
+            // Unmounted in the meantime or removed from the config
+            return state.Update(func(s *state.State) error {
+                if m := s.Get(mountPath); m != nil {
+                    m.Expiry = nil
+                }
+                return nil
+            })
+        }
+
+        opts := mount.UnmountOptions{}
+        if !busySince.IsZero() && time.Since(busySince) >= expireLazyAfter {
+            opts.Lazy = true
+        }
+        switch {
+        case attempt == 1:
+            fmt.Printf("%s: time limit reached, unmounting %s\n", time.Now().Format(time.RFC3339), entry.Name)
+        case opts.Lazy:
+            fmt.Printf("%s: %s has been busy for %s, detaching it\n", time.Now().Format(time.RFC3339), entry.Name, expireLazyAfter)
+        default:
+            fmt.Printf("%s: unmounting %s, attempt %d\n", time.Now().Format(time.RFC3339), entry.Name, attempt)
+        }
+
+        err := umountEntry(ctx, cfg, entry, opts, log)
+        if err == nil || ctx.Err() != nil {
+            return err
+        }
+        if !mount.IsBusy(err) {
+            busySince = time.Time{}
+        } else if busySince.IsZero() {
+            busySince = time.Now()
+        }
+        fmt.Printf("%s: failed to unmount %s: %v, retrying in %s\n", time.Now().Format(time.RFC3339), entry.Name, err, delay)
+
+        if !sleep(ctx, delay) {
+            return nil
+        }
+        delay = min(delay*2, expireRetryMax)
+
+        // Stop when the share was unmounted by hand or given a new time limit in the meantime
+        if expiry, err := ownExpiry(mountPath); err != nil || expiry == nil {
+            return err
+        }
+    }
+}
+
+// ownExpiry 返回由当前进程负责的到期记录，记录已被删除或由其他进程接管时返回 nil
+func ownExpiry(mountPath string) (*state.Expiry, error) {
+    s, err := state.Load()
+    if err != nil {
+        return nil, err
+    }
+    m := s.Get(mountPath)
+    if m == nil || m.Expiry == nil || m.Expiry.PID != os.Getpid() {
+        return nil, nil
+    }
+    return m.Expiry, nil
+}
+
+// sleep 等待 d，ctx 结束时提前返回 false
+func sleep(ctx context.Context, d time.Duration) bool {
+    select {
+    case <-time.After(d):
+        return true
+    case <-ctx.Done():
+        return false
+    }
+}
@@ -1,18 +1,42 @@
 package main
 
 import (
+    "bytes"
+    "context"
+    "errors"
     "fmt"
     "os"
+    "os/signal"
+    "slices"
+    "strings"
+    "syscall"
+    "time"
 
     "github.com/hsldymq/smb_mount/internal/config"
     "github.com/hsldymq/smb_mount/internal/interaction"
     "github.com/hsldymq/smb_mount/internal/mount"
+    "github.com/hsldymq/smb_mount/internal/network"
     "github.com/hsldymq/smb_mount/internal/tui"
+    "github.com/hsldymq/smb_mount/internal/tunnel"
     "github.com/spf13/cobra"
 )
 
 var (
     configPath string
+    strictEnv  bool
+    backend    string
+    timeout    time.Duration
+    listTags   []string
+    mountAll   bool
+    umountAll  bool
+    mountJobs  int
+    mountFor   time.Duration
+    umountJobs int
+    mountOpts  mount.MountOptions
+    umountOpts mount.UnmountOptions
+
+    // newMounter 创建挂载后端，测试中可替换为返回 mount.FakeMounter
+    newMounter = mount.NewMounter
 )
 
 var rootCmd = &cobra.Command{
@@ -37,51 +61,85 @@ var listCmd = &cobra.Command{
 }
 
 var mountCmd = &cobra.Command{
-    Use:     "mount [name]",
+    Use:     "mount [name|@tag|pattern...]",
     Aliases: []string{"m"},
     Short:   "挂载 SMB 共享",
-    Long: `挂载 SMB 共享。如果提供了名称，则挂载该特定共享。
-如果未提供名称，则显示交互式选择菜单。`,
-    Args: cobra.MaximumNArgs(1),
+    Long: `挂载 SMB 共享。参数可以是条目名称、@tag 或 glob 模式（如 nas-*），可以指定多个。
+使用 --all 挂载所有共享。如果未提供参数，则显示交互式选择菜单。`,
     RunE: runMount,
 }
 
 var umountCmd = &cobra.Command{
-    Use:     "umount [name]",
+    Use:     "umount [name|@tag|pattern...]",
     Aliases: []string{"u", "unmount"},
     Short:   "卸载 SMB 共享",
-    Long: `卸载 SMB 共享。如果提供了名称，则卸载该特定共享。
-如果未提供名称，则显示已挂载共享的交互式选择菜单。`,
-    Args: cobra.MaximumNArgs(1),
+    Long: `卸载 SMB 共享。参数可以是条目名称、@tag 或 glob 模式（如 nas-*），可以指定多个。
+使用 --all 卸载所有已挂载的共享。如果未提供参数，则显示已挂载共享的交互式选择菜单。`,
     RunE: runUmount,
 }
 
 func init() {
     rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "",
         fmt.Sprintf("配置文件路径 (默认: %s)", config.DefaultConfigPath()))
+    rootCmd.PersistentFlags().BoolVar(&strictEnv, "strict-env", false,
+        "配置中引用的环境变量未设置时报错")
+    rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0,
+        "单次挂载或卸载的超时时间，覆盖配置中的全局 timeout（例如 30s）")
+    rootCmd.PersistentFlags().StringVar(&backend, "backend", "",
+        "挂载后端：exec（调用 mount.cifs）或 syscall（直接调用 mount(2)），覆盖配置中的 backend")
+
+    listCmd.Flags().StringSliceVarP(&listTags, "tag", "t", nil, "只显示带有指定标签的条目（可重复）")
+    mountCmd.Flags().BoolVarP(&mountAll, "all", "a", false, "挂载所有共享")
+    umountCmd.Flags().BoolVarP(&umountAll, "all", "a", false, "卸载所有已挂载的共享")
+    mountCmd.Flags().IntVarP(&mountJobs, "jobs", "j", defaultJobs, "同时挂载的最大数量")
+    mountCmd.Flags().DurationVar(&mountFor, "for", 0, "挂载指定时长后自动卸载，覆盖配置中的 auto_unmount_after（例如 2h）")
+    mountCmd.Flags().BoolVar(&mountOpts.ForceTarget, "force-target", false, "跳过挂载目标的安全检查（非空目录、已有挂载点、系统目录、符号链接）")
+    umountCmd.Flags().IntVarP(&umountJobs, "jobs", "j", defaultJobs, "同时卸载的最大数量")
+    umountCmd.Flags().BoolVarP(&umountOpts.Lazy, "lazy", "l", false, "立即分离挂载点，不再被使用后才真正卸载（MNT_DETACH）")
+    umountCmd.Flags().BoolVarP(&umountOpts.Force, "force", "f", false, "服务器无响应时强制卸载（MNT_FORCE）")
 
     rootCmd.AddCommand(listCmd)
     rootCmd.AddCommand(mountCmd)
     rootCmd.AddCommand(umountCmd)
+    rootCmd.AddCommand(pingCmd)
+    rootCmd.AddCommand(wakeCmd)
+    rootCmd.AddCommand(tunnelCmd)
+    rootCmd.AddCommand(pruneCmd)
+    rootCmd.AddCommand(remountCmd)
+    rootCmd.AddCommand(recoverCmd)
+    rootCmd.AddCommand(execCmd)
+    rootCmd.AddCommand(shellCmd)
+    rootCmd.AddCommand(sessionCmd)
+    rootCmd.AddCommand(expireCmd)
+    rootCmd.AddCommand(reaperCmd)
 }
 
 func main() {
-    if err := rootCmd.Execute(); err != nil {
+    // Ctrl-C cancels running mount operations instead of killing the process outright
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+
+    if err := rootCmd.ExecuteContext(ctx); err != nil {
+        // exec 命令以被执行命令的退出码退出
+        var exitErr *exitCodeError
+        if errors.As(err, &exitErr) {
+            os.Exit(exitErr.code)
+        }
         fmt.Fprintf(os.Stderr, "Error: %v\n", err)
         os.Exit(1)
     }
 }
 
-// loadConfig 从指定或默认路径加载配置
+// loadConfig 从指定或默认路径加载配置，并输出配置格式和文件权限的提示
 func loadConfig() (*config.Config, error) {
-    path := configPath
-    if path == "" {
-        path = config.DefaultConfigPath()
+    cfg, err := readConfig()
+    if err != nil {
+        return nil, err
     }
+    path := resolveConfigPath()
 
-    cfg, err := config.Load(path)
-    if err != nil {
-        return nil, fmt.Errorf("failed to load config: %w", err)
+    if cfg.SourceVersion < config.CurrentVersion {
+        fmt.Fprintf(os.Stderr, "Note: config uses format version %d, run 'smb_mount config migrate' to upgrade it\n", cfg.SourceVersion)
     }
 
     // Check config file permissions
@@ -93,14 +151,42 @@ func loadConfig() (*config.Config, error) {
     return cfg, nil
 }
 
+// readConfig 从指定或默认路径加载配置，不输出提示，用于重复加载配置的长期运行的命令
+func readConfig() (*config.Config, error) {
+    cfg, err := config.LoadWithOptions(resolveConfigPath(), config.LoadOptions{StrictEnv: strictEnv})
+    if err != nil {
+        return nil, fmt.Errorf("failed to load config: %w", err)
+    }
+
+    if timeout > 0 {
+        cfg.Timeout = timeout
+    }
+    return cfg, nil
+}
+
+// configureBackend 根据命令行参数或配置选择挂载后端
+func configureBackend(cfg *config.Config) error {
+    name := backend
+    if name == "" {
+        name = cfg.Backend
+    }
+
+    m, err := newMounter(name)
+    if err != nil {
+        return err
+    }
+    mount.SetDefault(m)
+    return nil
+}
+
 // prepareMountEntry 准备挂载条目，如果需要则提示输入密码
-func prepareMountEntry(entry *config.MountEntry) error {
+// 提示前的条目信息写入 log；密码提示本身写到标准错误
+func prepareMountEntry(entry *config.MountEntry, log *entryLog) error {
     // If password is not in config, prompt for it
     if !entry.HasPassword() {
-        fmt.Printf("Mounting: %s\n", entry.Name)
-        fmt.Printf("SMB Address: %s:%d\n", entry.SMBAddr, entry.GetSMBPort())
-        fmt.Printf("Username: %s\n", entry.Username)
-        fmt.Println()
+        log.Printf("Mounting: %s\n", entry.Name)
+        log.Printf("SMB Address: %s:%d\n", entry.SMBAddr, entry.GetSMBPort())
+        log.Printf("Username: %s\n\n", entry.Username)
 
         password, err := interaction.PromptPassword("Enter password: ", true)
         if err != nil {
@@ -120,12 +206,32 @@ func runList(cmd *cobra.Command, args []string) error {
     }
 
     // Refresh mount status
-    if err := mount.RefreshAllStatus(cfg); err != nil {
+    if _, err := mount.RefreshAllStatus(cfg); err != nil {
         fmt.Fprintf(os.Stderr, "Warning: failed to refresh mount status: %v\n", err)
     }
+    mount.CheckAllHealth(cfg, cfg.GetProbeTimeout())
+    if err := loadExpiry(cfg); err != nil {
+        fmt.Fprintf(os.Stderr, "Warning: failed to read mount expiry: %v\n", err)
+    }
+
+    mounts := cfg.Mounts
+    if len(listTags) > 0 {
+        selectors := make([]string, len(listTags))
+        for i, tag := range listTags {
+            selectors[i] = config.TagPrefix + tag
+        }
+        selected, err := cfg.Select(selectors)
+        if err != nil {
+            return err
+        }
+        mounts = make([]config.MountEntry, len(selected))
+        for i, entry := range selected {
+            mounts[i] = *entry
+        }
+    }
 
     // Display list using TUI
-    if err := tui.DisplayList(cfg.Mounts); err != nil {
+    if err := tui.DisplayList(mounts); err != nil {
         return fmt.Errorf("failed to display list: %w", err)
     }
 
@@ -138,17 +244,23 @@ func runMount(cmd *cobra.Command, args []string) error {
     if err != nil {
         return err
     }
+    if err := configureBackend(cfg); err != nil {
+        return err
+    }
 
     // 首先刷新挂载状态
-    if err := mount.RefreshAllStatus(cfg); err != nil {
+    if _, err := mount.RefreshAllStatus(cfg); err != nil {
         fmt.Fprintf(os.Stderr, "Warning: failed to refresh mount status: %v\n", err)
     }
 
     var entries []*config.MountEntry
 
     // 确定要挂载的条目
-    if len(args) == 0 {
+    if mountAll {
+        entries = cfg.All()
+    } else if len(args) == 0 {
         // 交互式选择
+        mount.CheckAllHealth(cfg, cfg.GetProbeTimeout())
         selected, cancelled := tui.SelectMountEntry(cfg.Mounts)
         if cancelled {
             fmt.Println("Cancelled")
@@ -160,13 +272,12 @@ func runMount(cmd *cobra.Command, args []string) error {
         }
         entries = selected
     } else {
-        // 按名称查找
-        name := args[0]
-        entry, found := cfg.FindByName(name)
-        if !found {
-            return fmt.Errorf("mount entry '%s' not found", name)
+        // 按名称、标签或模式查找
+        selected, err := cfg.Select(args)
+        if err != nil {
+            return err
         }
-        entries = []*config.MountEntry{entry}
+        entries = selected
     }
 
     // 确保基础目录存在
@@ -174,51 +285,59 @@ func runMount(cmd *cobra.Command, args []string) error {
         return fmt.Errorf("failed to prepare base directory: %w", err)
     }
 
-    // 批量挂载
+    // 在开始并行挂载前统一提示输入密码，避免提示交错
+    var pending []*config.MountEntry
     var successCount, failCount int
-    fmt.Printf("Mounting %d share(s)...\n\n", len(entries))
-
-    for i, entry := range entries {
-        fmt.Printf("[%d/%d] %s\n", i+1, len(entries), entry.Name)
-
-        // 检查是否已挂载
+    for _, entry := range entries {
         if entry.IsMounted {
-            fmt.Printf("  Already mounted at: %s\n\n", entry.ActualMountPath)
-            successCount++
+            pending = append(pending, entry)
             continue
         }
-
-        // 准备条目（如果需要则提示输入密码）
-        if err := prepareMountEntry(entry); err != nil {
-            fmt.Fprintf(os.Stderr, "  Failed to prepare: %v\n\n", err)
+        if err := prepareMountEntry(entry, &entryLog{direct: true}); err != nil {
+            fmt.Fprintf(os.Stderr, "%s: failed to prepare: %v\n\n", entry.Name, err)
             failCount++
             continue
         }
+        pending = append(pending, entry)
+    }
+
+    // 前置条目先挂载，嵌套的挂载路径在外层共享挂载之后挂载
+    pending, after, err := batchOrder(cfg, pending, false)
+    if err != nil {
+        return err
+    }
 
-        // 执行挂载
-        fmt.Printf("  From: //%s:%d/%s\n", entry.SMBAddr, entry.GetSMBPort(), entry.ShareName)
-        fmt.Printf("  To: %s\n", entry.ActualMountPath)
-
-        if err := mount.Mount(entry); err != nil {
-            // 检查是否需要使用 sudo 重试
-            if interaction.NeedsPrivilege() {
-                fmt.Println("  Privilege escalation required...")
-                if err := mountWithSudo(entry); err != nil {
-                    fmt.Fprintf(os.Stderr, "  Failed: %v\n\n", err)
-                    failCount++
-                    continue
-                }
-            } else {
-                fmt.Fprintf(os.Stderr, "  Failed: %v\n\n", err)
-                failCount++
-                continue
+    // 批量挂载
+    fmt.Printf("Mounting %d share(s)...\n\n", len(pending))
+    ctx := cmd.Context()
+    succeeded, failed := runBatchAfter(pending, mountJobs, after, func(entry *config.MountEntry, log *entryLog) error {
+        if err := checkPrerequisites(cfg, entry, pending); err != nil {
+            return err
+        }
+        // --for 也作用于已挂载的共享；配置中的时限只在新挂载时生效
+        // 到期时无法卸载的限时挂载在挂载前拒绝
+        limit := mountFor
+        if limit == 0 && !entry.IsMounted {
+            limit = entry.AutoUnmountAfter
+        }
+        if limit > 0 {
+            if err := checkUnattendedUnmount(); err != nil {
+                return err
             }
         }
 
-        fmt.Println("  Successfully mounted")
-        successCount++
-        fmt.Println()
-    }
+        if err := mountEntry(ctx, cfg, entry, log); err != nil {
+            return err
+        }
+        if limit > 0 {
+            if err := scheduleUnmount(entry, limit, log); err != nil {
+                return fmt.Errorf("mounted, but failed to schedule unmount: %w", err)
+            }
+        }
+        return nil
+    })
+    successCount += succeeded
+    failCount += failed
 
     // 汇总结果
     fmt.Println("==========================================")
@@ -239,17 +358,27 @@ func runUmount(cmd *cobra.Command, args []string) error {
     if err != nil {
         return err
     }
+    if err := configureBackend(cfg); err != nil {
+        return err
+    }
 
     // 首先刷新挂载状态
-    if err := mount.RefreshAllStatus(cfg); err != nil {
+    if _, err := mount.RefreshAllStatus(cfg); err != nil {
         fmt.Fprintf(os.Stderr, "Warning: failed to refresh mount status: %v\n", err)
     }
 
     var entries []*config.MountEntry
 
     // 确定要卸载的条目
-    if len(args) == 0 {
+    if umountAll {
+        entries = mountedEntries(cfg.All())
+        if len(entries) == 0 {
+            fmt.Println("No mounted shares available")
+            return nil
+        }
+    } else if len(args) == 0 {
         // 交互式选择（只显示已挂载的条目）
+        mount.CheckAllHealth(cfg, cfg.GetProbeTimeout())
         selected, cancelled := tui.SelectUnmountEntry(cfg.Mounts)
         if cancelled {
             fmt.Println("Cancelled")
@@ -261,48 +390,58 @@ func runUmount(cmd *cobra.Command, args []string) error {
         }
         entries = selected
     } else {
-        // 按名称查找
-        name := args[0]
-        entry, found := cfg.FindByName(name)
-        if !found {
-            return fmt.Errorf("mount entry '%s' not found", name)
+        // 按名称、标签或模式查找
+        selected, err := cfg.Select(args)
+        if err != nil {
+            return err
         }
 
-        // 检查是否已挂载
-        if !entry.IsMounted {
-            return fmt.Errorf("not mounted: %s", entry.Name)
+        // 跳过未挂载的条目
+        entries = mountedEntries(selected)
+        for _, entry := range selected {
+            switch {
+            case entry.ForeignMount != "":
+                fmt.Fprintf(os.Stderr, "Skipping %s: %s is a mount point of %s, not of this share\n", entry.Name, entry.ActualMountPath, entry.ForeignMount)
+            case !entry.IsMounted:
+                fmt.Fprintf(os.Stderr, "Skipping %s: not mounted\n", entry.Name)
+            }
+        }
+        if len(entries) == 0 {
+            return fmt.Errorf("none of the selected shares are mounted")
         }
-        entries = []*config.MountEntry{entry}
+    }
+
+    // 依赖它的条目先卸载
+    entries, after, err := batchOrder(cfg, entries, true)
+    if err != nil {
+        return err
     }
 
     // 批量卸载
-    var successCount, failCount int
     fmt.Printf("Unmounting %d share(s)...\n\n", len(entries))
-
-    for i, entry := range entries {
-        fmt.Printf("[%d/%d] %s\n", i+1, len(entries), entry.Name)
-        fmt.Printf("  From: %s\n", entry.ActualMountPath)
-
-        // 执行卸载
-        if err := mount.Unmount(entry.ActualMountPath); err != nil {
-            // 检查是否需要使用 sudo 重试
-            if interaction.NeedsPrivilege() {
-                fmt.Println("  Privilege escalation required...")
-                if err := umountWithSudo(entry.ActualMountPath); err != nil {
-                    fmt.Fprintf(os.Stderr, "  Failed: %v\n\n", err)
-                    failCount++
-                    continue
-                }
-            } else {
-                fmt.Fprintf(os.Stderr, "  Failed: %v\n\n", err)
-                failCount++
-                continue
+    ctx := cmd.Context()
+    busy := &busyMounts{}
+    umount := func(entry *config.MountEntry, log *entryLog) error {
+        for i := range cfg.Mounts {
+            other := &cfg.Mounts[i]
+            if other.IsMounted && !slices.Contains(entries, other) && slices.Contains(cfg.Dependencies(other), entry) {
+                return fmt.Errorf("%s depends on it and is still mounted", other.Name)
             }
         }
-
-        fmt.Println("  Successfully unmounted")
-        successCount++
-        fmt.Println()
+        err := umountEntry(ctx, cfg, entry, umountOpts, log)
+        if mount.IsBusy(err) {
+            busy.report(entry, log)
+        }
+        return err
+    }
+    successCount, failCount := runBatchAfter(entries, umountJobs, after, umount)
+
+    // 挂载点被占用时，提示结束占用的进程后重试
+    if retry := busy.offerSignal(); len(retry) > 0 {
+        fmt.Printf("Retrying %d share(s)...\n\n", len(retry))
+        succeeded, failed := runBatch(retry, umountJobs, umount)
+        successCount += succeeded
+        failCount += failed - len(retry)
     }
 
     // 汇总结果
@@ -318,11 +457,246 @@ func runUmount(cmd *cobra.Command, args []string) error {
     return nil
 }
 
+// checkPrerequisites 检查条目依赖的条目已挂载或在同一批中先于它挂载
+func checkPrerequisites(cfg *config.Config, entry *config.MountEntry, batch []*config.MountEntry) error {
+    for _, dep := range cfg.Dependencies(entry) {
+        if !dep.IsMounted && !slices.Contains(batch, dep) {
+            return fmt.Errorf("%s must be mounted first", dep.Name)
+        }
+    }
+    return nil
+}
+
+// mountEntry 挂载单个条目，遇到临时网络错误时按重试策略重试
+func mountEntry(ctx context.Context, cfg *config.Config, entry *config.MountEntry, log *entryLog) error {
+    // 检查是否已挂载
+    if entry.IsMounted {
+        log.Printf("  Already mounted at: %s\n", entry.ActualMountPath)
+        if entry.IsStale {
+            log.Printf("  The mount is not responding, run 'smb_mount recover %s' to mount it again\n", entry.Name)
+        }
+        return nil
+    }
+    // 挂载点上是其他文件系统时，无需连接服务器即可报错
+    if entry.ForeignMount != "" && !mountOpts.ForceTarget {
+        return fmt.Errorf("%s is already a mount point of %s (use --force-target to mount anyway)", entry.ActualMountPath, entry.ForeignMount)
+    }
+    if err := ctx.Err(); err != nil {
+        return fmt.Errorf("cancelled")
+    }
+
+    // 执行挂载
+    log.Printf("  From: //%s:%d/%s\n", entry.SMBAddr, entry.GetSMBPort(), entry.ShareName)
+    log.Printf("  To: %s\n", entry.ActualMountPath)
+
+    var useSudo bool
+    policy := entry.GetRetryPolicy(cfg.Retry)
+    err := mount.Retry(ctx, policy, func(attempt int) error {
+        return mountAttempt(ctx, cfg, entry, log, &useSudo)
+    }, func(attempt int, err error, delay time.Duration) {
+        log.Printf("  Attempt %d/%d failed: %s\n", attempt, policy.Attempts, firstLine(err))
+        log.Printf("  Retrying in %s...\n", delay.Round(100*time.Millisecond))
+    })
+    if err != nil {
+        return err
+    }
+
+    log.Printf("  Successfully mounted\n")
+    return nil
+}
+
+// mountAttempt 执行一次挂载尝试，需要时使用 sudo 重试
+// 每次尝试受条目的超时时间限制，超时或取消时终止挂载进程
+// 一旦需要权限提升，useSudo 被设置，后续尝试直接使用 sudo
+// 配置了 tunnel 的条目经由 SSH 隧道挂载，挂载失败时停止隧道
+func mountAttempt(ctx context.Context, cfg *config.Config, entry *config.MountEntry, log *entryLog, useSudo *bool) (err error) {
+    // 先确认服务器可达，不可达时跳过挂载和 sudo 提示
+    // 唤醒服务器的等待时间不计入挂载的超时时间
+    // 经由隧道访问的服务器无法直接探测
+    if entry.Tunnel == nil {
+        if err := reachServer(ctx, cfg, entry, log); err != nil {
+            if ctx.Err() != nil {
+                return fmt.Errorf("cancelled")
+            }
+            return err
+        }
+    }
+
+    limit := entry.GetTimeout(cfg.Timeout)
+    ctx, cancel := context.WithTimeout(ctx, limit)
+    defer cancel()
+
+    if entry.Tunnel != nil {
+        t, tunnelErr := startTunnel(ctx, entry, log)
+        if tunnelErr != nil {
+            return describeCtxErr(ctx, limit, tunnelErr)
+        }
+        defer func() {
+            if err != nil {
+                _ = tunnel.Stop(t.PID)
+                entry.TunnelPort = 0
+                return
+            }
+            if recErr := recordTunnel(entry, t); recErr != nil {
+                log.Errorf("  Warning: failed to record tunnel: %v\n", recErr)
+            }
+        }()
+    }
+
+    if !*useSudo {
+        err := mount.Mount(ctx, entry, mountOpts)
+        if err == nil {
+            return nil
+        }
+        var targetErr *mount.TargetError
+        if errors.As(err, &targetErr) {
+            return fmt.Errorf("%w (use --force-target to mount anyway)", err)
+        }
+        // 检查是否需要使用 sudo 重试
+        if ctx.Err() != nil || mount.IsTransient(err) || !interaction.NeedsPrivilege() {
+            return describeCtxErr(ctx, limit, err)
+        }
+        if sudoErr := checkSudoBackend(err); sudoErr != nil {
+            return sudoErr
+        }
+        log.Printf("  Privilege escalation required...\n")
+        *useSudo = true
+    }
+
+    if err := mountWithSudo(ctx, entry); err != nil {
+        return describeCtxErr(ctx, limit, err)
+    }
+    return nil
+}
+
+// reachServer 探测服务器是否可达，并将第一个可达的地址记录为条目的 ActiveAddr
+// 配置了备用地址时同时探测；都不可达且配置了 wol 时发送唤醒包并等待服务器启动
+func reachServer(ctx context.Context, cfg *config.Config, entry *config.MountEntry, log *entryLog) error {
+    addrs := entry.Addresses()
+    reachable, err := network.FirstReachable(network.ProbeAll(ctx, addrs, entry.GetSMBPort(), cfg.GetProbeTimeout()))
+
+    if err != nil && entry.WakeOnLAN != nil && ctx.Err() == nil {
+        wol := entry.WakeOnLAN
+        log.Printf("  Server not reachable, sending Wake-on-LAN packet to %s\n", wol.MAC)
+        if err := network.SendMagicPacket(wol.MAC, wol.GetBroadcast(), wol.GetPort()); err != nil {
+            return err
+        }
+
+        log.Printf("  Waiting up to %s for the server to come up...\n", wol.GetWait())
+        start := time.Now()
+        reachable, err = network.WaitReachable(ctx, addrs, entry.GetSMBPort(), cfg.GetProbeTimeout(), wol.GetWait())
+        if err == nil {
+            log.Printf("  Server is up after %s\n", time.Since(start).Round(time.Second))
+        }
+    }
+    if err != nil {
+        return err
+    }
+
+    if reachable.Host != entry.GetSMBAddr() {
+        log.Printf("  Using fallback address: %s\n", reachable.Host)
+    }
+    entry.ActiveAddr = reachable.Host
+    return nil
+}
+
+// firstLine 返回错误信息的第一行，用于简短地报告失败的尝试
+func firstLine(err error) string {
+    msg, _, _ := strings.Cut(err.Error(), "\n")
+    return msg
+}
+
+// umountEntry 卸载单个条目，需要时使用 sudo 重试
+func umountEntry(ctx context.Context, cfg *config.Config, entry *config.MountEntry, opts mount.UnmountOptions, log *entryLog) error {
+    log.Printf("  From: %s\n", entry.ActualMountPath)
+    if err := ctx.Err(); err != nil {
+        return fmt.Errorf("cancelled")
+    }
+
+    limit := entry.GetTimeout(cfg.Timeout)
+    ctx, cancel := context.WithTimeout(ctx, limit)
+    defer cancel()
+
+    // 执行卸载
+    if err := mount.Unmount(ctx, entry.ActualMountPath, opts); err != nil {
+        // 检查是否需要使用 sudo 重试
+        if ctx.Err() != nil || !interaction.NeedsPrivilege() {
+            return describeCtxErr(ctx, limit, err)
+        }
+        if sudoErr := checkSudoBackend(err); sudoErr != nil {
+            return sudoErr
+        }
+        log.Printf("  Privilege escalation required...\n")
+        if err := umountWithSudo(ctx, entry.ActualMountPath, opts); err != nil {
+            return describeCtxErr(ctx, limit, err)
+        }
+    }
+
+    log.Printf("  Successfully unmounted\n")
+
+    // 停止挂载时启动的 SSH 隧道
+    if err := stopTunnel(entry, log); err != nil {
+        log.Errorf("  Warning: failed to stop tunnel: %v\n", err)
+    }
+
+    // 限时挂载已提前卸载时不再需要到期进程
+    if err := stopExpiry(entry); err != nil {
+        log.Errorf("  Warning: failed to cancel scheduled unmount: %v\n", err)
+    }
+
+    // 删除 smb_mount 创建的挂载目录
+    if entry.GetCleanup(cfg.Cleanup) {
+        removed, err := mount.CleanupMountPoint(entry.ActualMountPath)
+        if err != nil {
+            log.Errorf("  Warning: failed to clean up mount directory: %v\n", err)
+        }
+        for _, dir := range removed {
+            log.Printf("  Removed directory: %s\n", dir)
+        }
+    }
+    return nil
+}
+
+// describeCtxErr 在操作因超时或取消而失败时返回更明确的错误
+func describeCtxErr(ctx context.Context, limit time.Duration, err error) error {
+    switch {
+    case errors.Is(ctx.Err(), context.DeadlineExceeded):
+        return fmt.Errorf("timed out after %s", limit)
+    case errors.Is(ctx.Err(), context.Canceled):
+        return fmt.Errorf("cancelled")
+    default:
+        return err
+    }
+}
+
+// checkSudoBackend 检查当前后端需要权限时能否通过 sudo 重试
+// sudo 重试执行的是 mount.cifs、umount 和 mount 命令，与 exec 后端相同；其他后端不依赖 cifs-utils，需要以 root 运行
+func checkSudoBackend(err error) error {
+    if name := mount.Default().Name(); name != mount.BackendExec {
+        return fmt.Errorf("%w (the %s backend does not use sudo, run smb_mount as root or use --backend exec)", err, name)
+    }
+    return nil
+}
+
 // mountWithSudo 尝试使用权限提升进行挂载
-func mountWithSudo(entry *config.MountEntry) error {
+// 失败时删除本次尝试中创建的目录
+func mountWithSudo(ctx context.Context, entry *config.MountEntry) error {
+    if !mountOpts.ForceTarget {
+        if err := mount.CheckTarget(entry); err != nil {
+            return err
+        }
+    }
+
+    // Create mount directory if it doesn't exist
+    created, err := mount.CreateMountPoint(entry)
+    if err != nil {
+        return err
+    }
+
     // Create a temporary credentials file
     credsFile, err := mount.CreateCredentialFile(entry)
     if err != nil {
+        mount.RemoveCreated(created)
         return err
     }
     defer os.Remove(credsFile)
@@ -330,21 +704,44 @@ func mountWithSudo(entry *config.MountEntry) error {
     // Build mount command
     cmd := mount.BuildMountCommand(entry, credsFile)
 
-    // Execute with sudo
-    if err := interaction.RunWithSudo(cmd); err != nil {
-        return fmt.Errorf("mount with sudo failed: %w", err)
+    // Execute with sudo, keeping the output to classify failures
+    var output bytes.Buffer
+    cmd.Stdout = &output
+    cmd.Stderr = &output
+    if err := interaction.RunWithSudo(ctx, cmd); err != nil {
+        mount.RemoveCreated(created)
+        return mount.CommandError("mount with sudo", err, output.Bytes())
+    }
+
+    if err := mount.RecordCreated(entry, created); err != nil {
+        fmt.Fprintf(os.Stderr, "Warning: failed to record created directories for %s: %v\n", entry.Name, err)
     }
 
     return nil
 }
 
 // umountWithSudo 尝试使用权限提升进行卸载
-func umountWithSudo(mountPath string) error {
-    cmd := mount.BuildUmountCommand(mountPath)
-
-    if err := interaction.RunWithSudo(cmd); err != nil {
-        return fmt.Errorf("unmount with sudo failed: %w", err)
+func umountWithSudo(ctx context.Context, mountPath string, opts mount.UnmountOptions) error {
+    cmd := mount.BuildUmountCommand(mountPath, opts)
+
+    // Keep the output to detect a busy target
+    var output bytes.Buffer
+    cmd.Stdout = &output
+    cmd.Stderr = &output
+    if err := interaction.RunWithSudo(ctx, cmd); err != nil {
+        return mount.CommandError("unmount with sudo", err, output.Bytes())
     }
 
     return nil
 }
+
+// mountedEntries 返回已挂载的条目
+func mountedEntries(entries []*config.MountEntry) []*config.MountEntry {
+    var mounted []*config.MountEntry
+    for _, entry := range entries {
+        if entry.IsMounted {
+            mounted = append(mounted, entry)
+        }
+    }
+    return mounted
+}
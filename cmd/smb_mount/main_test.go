@@ -0,0 +1,257 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net"
+    "os"
+    "path/filepath"
+    "slices"
+    "strings"
+    "testing"
+
+    "github.com/hsldymq/smb_mount/internal/mount"
+    "github.com/spf13/cobra"
+)
+
+// testEnv 是使用内存后端运行命令的测试环境
+type testEnv struct {
+    fake    *mount.FakeMounter
+    baseDir string
+}
+
+// newTestEnv 为给定名称的条目写入配置文件，并将挂载后端替换为 mount.FakeMounter
+// 条目的服务器指向本地监听的端口，挂载前的可达性检查可以通过
+func newTestEnv(t *testing.T, names ...string) *testEnv {
+    t.Helper()
+
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { listener.Close() })
+    port := listener.Addr().(*net.TCPAddr).Port
+
+    dir := t.TempDir()
+    env := &testEnv{fake: mount.NewFakeMounter(), baseDir: filepath.Join(dir, "mnt")}
+
+    var b strings.Builder
+    fmt.Fprintf(&b, "version: 2\nbase_dir: %s\nmounts:\n", env.baseDir)
+    for _, name := range names {
+        fmt.Fprintf(&b, "  - {name: %s, smb_addr: 127.0.0.1, smb_port: %d, share_name: %s, username: u, password: p}\n", name, port, name)
+    }
+    path := filepath.Join(dir, "config.yaml")
+    if err := os.WriteFile(path, []byte(b.String()), 0600); err != nil {
+        t.Fatal(err)
+    }
+
+    t.Setenv("XDG_STATE_HOME", filepath.Join(dir, "state"))
+    resetFlags()
+    configPath = path
+    newMounter = func(string) (mount.Mounter, error) { return env.fake, nil }
+    previous := mount.Default()
+    t.Cleanup(func() {
+        resetFlags()
+        newMounter = mount.NewMounter
+        mount.SetDefault(previous)
+    })
+    return env
+}
+
+// resetFlags 将命令行参数恢复为默认值
+func resetFlags() {
+    configPath = ""
+    strictEnv = false
+    backend = ""
+    timeout = 0
+    mountAll = false
+    umountAll = false
+    mountJobs = defaultJobs
+    umountJobs = defaultJobs
+    mountFor = 0
+    mountOpts = mount.MountOptions{}
+    umountOpts = mount.UnmountOptions{}
+    sessionUID = -1
+    sessionGID = -1
+}
+
+// path 返回条目的挂载路径
+func (e *testEnv) path(name string) string {
+    return filepath.Join(e.baseDir, name)
+}
+
+// umountCalls 返回内存后端收到的卸载调用
+func (e *testEnv) umountCalls() []string {
+    var calls []string
+    for _, call := range e.fake.Calls {
+        if strings.HasPrefix(call, "umount ") {
+            calls = append(calls, call)
+        }
+    }
+    return calls
+}
+
+// mounted 返回内存后端中已挂载的路径
+func (e *testEnv) mounted(t *testing.T) []string {
+    t.Helper()
+    mounts, err := e.fake.Mounts()
+    if err != nil {
+        t.Fatal(err)
+    }
+    var paths []string
+    for _, m := range mounts {
+        paths = append(paths, m.Mountpoint)
+    }
+    slices.Sort(paths)
+    return paths
+}
+
+// run 执行命令函数，返回其标准输出、标准错误和错误
+func run(t *testing.T, fn func(cmd *cobra.Command, args []string) error, args ...string) (stdout, stderr string, err error) {
+    t.Helper()
+
+    outFile, err := os.CreateTemp(t.TempDir(), "stdout")
+    if err != nil {
+        t.Fatal(err)
+    }
+    errFile, err := os.CreateTemp(t.TempDir(), "stderr")
+    if err != nil {
+        t.Fatal(err)
+    }
+    origOut, origErr := os.Stdout, os.Stderr
+    os.Stdout, os.Stderr = outFile, errFile
+    defer func() { os.Stdout, os.Stderr = origOut, origErr }()
+
+    cmd := &cobra.Command{}
+    cmd.SetContext(context.Background())
+    err = fn(cmd, args)
+
+    out, _ := os.ReadFile(outFile.Name())
+    errOut, _ := os.ReadFile(errFile.Name())
+    return string(out), string(errOut), err
+}
+
+func TestRunMountSummary(t *testing.T) {
+    env := newTestEnv(t, "a", "b", "c")
+    env.fake.MountErrors["b"] = errors.New("mount failed: permission denied")
+
+    stdout, _, err := run(t, runMount, "a", "b", "c")
+    if err != nil {
+        t.Fatalf("partial success should not return an error, got %v", err)
+    }
+    if !strings.Contains(stdout, "Mount complete: 2 succeeded, 1 failed") {
+        t.Errorf("summary missing from output:\n%s", stdout)
+    }
+    if want := []string{env.path("a"), env.path("c")}; !slices.Equal(env.mounted(t), want) {
+        t.Errorf("mounted = %v, want %v", env.mounted(t), want)
+    }
+}
+
+func TestRunMountAllFailed(t *testing.T) {
+    env := newTestEnv(t, "a", "b")
+    env.fake.MountErrors["a"] = errors.New("mount failed: permission denied")
+    env.fake.MountErrors["b"] = errors.New("mount failed: permission denied")
+
+    stdout, _, err := run(t, runMount, "a", "b")
+    if err == nil || err.Error() != "2 mount(s) failed" {
+        t.Errorf("err = %v, want 2 mount(s) failed", err)
+    }
+    if !strings.Contains(stdout, "Mount complete: 0 succeeded, 2 failed") {
+        t.Errorf("summary missing from output:\n%s", stdout)
+    }
+}
+
+func TestRunMountAlreadyMounted(t *testing.T) {
+    env := newTestEnv(t, "a")
+    if _, _, err := run(t, runMount, "a"); err != nil {
+        t.Fatal(err)
+    }
+
+    stdout, _, err := run(t, runMount, "a")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.Contains(stdout, "Already mounted at: "+env.path("a")) {
+        t.Errorf("expected already mounted message:\n%s", stdout)
+    }
+    if got := slices.Index(env.fake.Calls, "mount a"); got != len(env.fake.Calls)-1 {
+        t.Errorf("second run called the backend again: %v", env.fake.Calls)
+    }
+}
+
+func TestRunUmountSkipsUnmounted(t *testing.T) {
+    env := newTestEnv(t, "a", "b")
+    if _, _, err := run(t, runMount, "a"); err != nil {
+        t.Fatal(err)
+    }
+
+    stdout, stderr, err := run(t, runUmount, "a", "b")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := []string{"umount " + env.path("a")}; !slices.Equal(env.umountCalls(), want) {
+        t.Errorf("umount calls = %v, want %v", env.umountCalls(), want)
+    }
+    if !strings.Contains(stderr, "Skipping b: not mounted") {
+        t.Errorf("expected skip message:\n%s", stderr)
+    }
+    if !strings.Contains(stdout, "Unmount complete: 1 succeeded, 0 failed") {
+        t.Errorf("summary missing from output:\n%s", stdout)
+    }
+    if len(env.mounted(t)) != 0 {
+        t.Errorf("still mounted: %v", env.mounted(t))
+    }
+}
+
+func TestRunUmountNothingMounted(t *testing.T) {
+    env := newTestEnv(t, "a")
+
+    _, _, err := run(t, runUmount, "a")
+    if err == nil {
+        t.Fatal("expected an error when none of the shares are mounted")
+    }
+    if len(env.umountCalls()) != 0 {
+        t.Errorf("unexpected umount calls: %v", env.umountCalls())
+    }
+}
+
+func TestRunUmountAll(t *testing.T) {
+    env := newTestEnv(t, "a", "b", "c")
+    if _, _, err := run(t, runMount, "a", "c"); err != nil {
+        t.Fatal(err)
+    }
+
+    umountAll = true
+    stdout, _, err := run(t, runUmount)
+    if err != nil {
+        t.Fatal(err)
+    }
+    calls := env.umountCalls()
+    slices.Sort(calls)
+    if want := []string{"umount " + env.path("a"), "umount " + env.path("c")}; !slices.Equal(calls, want) {
+        t.Errorf("umount calls = %v, want %v", calls, want)
+    }
+    if !strings.Contains(stdout, "Unmount complete: 2 succeeded, 0 failed") {
+        t.Errorf("summary missing from output:\n%s", stdout)
+    }
+}
+
+func TestRunUmountFailure(t *testing.T) {
+    env := newTestEnv(t, "a")
+    if _, _, err := run(t, runMount, "a"); err != nil {
+        t.Fatal(err)
+    }
+    env.fake.UnmountErrors[env.path("a")] = errors.New("umount failed: permission denied")
+
+    stdout, _, err := run(t, runUmount, "a")
+    if err == nil || err.Error() != "1 unmount(s) failed" {
+        t.Errorf("err = %v, want 1 unmount(s) failed", err)
+    }
+    if !strings.Contains(stdout, "Unmount complete: 0 succeeded, 1 failed") {
+        t.Errorf("summary missing from output:\n%s", stdout)
+    }
+    if want := []string{env.path("a")}; !slices.Equal(env.mounted(t), want) {
+        t.Errorf("mounted = %v, want %v", env.mounted(t), want)
+    }
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "sync"
+    "text/tabwriter"
+    "time"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/hsldymq/smb_mount/internal/network"
+    "github.com/spf13/cobra"
+)
+
+var pingCmd = &cobra.Command{
+    Use:   "ping [name|@tag|pattern...]",
+    Short: "检查 SMB 服务器是否可达",
+    Long: `尝试与每个条目的 SMB 地址和端口建立 TCP 连接，报告连接延迟，
+或区分 DNS 解析失败、主机不可达和端口关闭。未提供参数时检查所有条目。
+超时时间由配置中的 probe_timeout 决定。`,
+    RunE: runPing,
+}
+
+// runPing 实现 ping 命令
+func runPing(cmd *cobra.Command, args []string) error {
+    cfg, err := loadConfig()
+    if err != nil {
+        return err
+    }
+
+    entries := cfg.All()
+    if len(args) > 0 {
+        if entries, err = cfg.Select(args); err != nil {
+            return err
+        }
+    }
+
+    // 并发探测，按配置顺序输出；配置了备用地址的条目每个地址一行
+    results := make([][]network.ProbeResult, len(entries))
+    var wg sync.WaitGroup
+    for i, entry := range entries {
+        wg.Add(1)
+        go func(i int, entry *config.MountEntry) {
+            defer wg.Done()
+            results[i] = network.ProbeAll(cmd.Context(), entry.Addresses(), entry.GetSMBPort(), cfg.GetProbeTimeout())
+        }(i, entry)
+    }
+    wg.Wait()
+
+    var failed int
+    w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+    for i, entry := range entries {
+        name := entry.Name
+        for _, result := range results[i] {
+            detail := result.Detail()
+            if result.OK() {
+                detail = formatLatency(result.Latency)
+            }
+            fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, result.Address(), result.Status, detail)
+            name = ""
+        }
+        if _, err := network.FirstReachable(results[i]); err != nil {
+            failed++
+        }
+    }
+    w.Flush()
+
+    if failed > 0 {
+        return fmt.Errorf("%d of %d server(s) not reachable", failed, len(entries))
+    }
+    return nil
+}
+
+// formatLatency 以毫秒显示连接延迟
+func formatLatency(d time.Duration) string {
+    return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+    "fmt"
+    "path/filepath"
+    "strings"
+
+    "github.com/hsldymq/smb_mount/internal/mount"
+    "github.com/spf13/cobra"
+)
+
+var pruneDryRun bool
+
+var pruneCmd = &cobra.Command{
+    Use:   "prune",
+    Short: "删除遗留的空挂载目录",
+    Long: `删除 smb_mount 创建的、当前未挂载且为空的挂载目录。
+只处理 base_dir 下以及配置中条目的挂载目录；用户自己创建的目录和非空目录不会被删除。`,
+    Args: cobra.NoArgs,
+    RunE: runPrune,
+}
+
+func init() {
+    pruneCmd.Flags().BoolVarP(&pruneDryRun, "dry-run", "n", false, "只显示将被删除的目录")
+}
+
+// runPrune 实现 prune 命令
+func runPrune(cmd *cobra.Command, args []string) error {
+    cfg, err := loadConfig()
+    if err != nil {
+        return err
+    }
+    if err := configureBackend(cfg); err != nil {
+        return err
+    }
+
+    targets := make(map[string]bool)
+    for _, entry := range cfg.All() {
+        targets[entry.ActualMountPath] = true
+    }
+    include := func(path string) bool {
+        rel, err := filepath.Rel(cfg.BaseDir, path)
+        return targets[path] || (err == nil && rel != ".." && !strings.HasPrefix(rel, "../"))
+    }
+
+    pruned, err := mount.Prune(include, pruneDryRun)
+    if err != nil {
+        return fmt.Errorf("failed to prune mount directories: %w", err)
+    }
+
+    verb := "Removed"
+    if pruneDryRun {
+        verb = "Would remove"
+    }
+    for _, dir := range pruned {
+        fmt.Printf("%s %s\n", verb, dir)
+    }
+    if len(pruned) == 0 {
+        fmt.Println("Nothing to prune")
+    }
+    return nil
+}
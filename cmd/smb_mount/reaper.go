@@ -0,0 +1,188 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "slices"
+    "time"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/hsldymq/smb_mount/internal/mount"
+    "github.com/spf13/cobra"
+)
+
+// defaultReaperInterval 是 reaper 检查挂载是否被使用的默认间隔
+const defaultReaperInterval = time.Minute
+
+var reaperInterval time.Duration
+
+var reaperCmd = &cobra.Command{
+    Use:   "reaper",
+    Short: "卸载长时间未使用的共享",
+    Long: `持续运行，定期检查设置了 idle_timeout 的已挂载共享是否被使用，卸载超过 idle_timeout 未被使用的共享。
+有进程的工作目录、打开的文件或内存映射位于挂载点下，或 /proc/fs/cifs/Stats 中该共享的请求数增加时，视为正在使用。
+空闲时间从 reaper 第一次看到挂载时开始计算。可以作为 systemd 服务运行，按 Ctrl-C 退出。`,
+    Args: cobra.NoArgs,
+    RunE: runReaper,
+}
+
+func init() {
+    reaperCmd.Flags().DurationVar(&reaperInterval, "interval", defaultReaperInterval, "检查挂载是否被使用的间隔")
+}
+
+// idleShare 是 reaper 对一个挂载最近一次活动的记录
+type idleShare struct {
+    source   string    // 挂载来源，挂载点上换成其他共享时重新计时
+    requests uint64    // 上次检查时共享已发送的 SMB 请求数
+    lastUsed time.Time // 最近一次发现挂载被使用的时间
+    heldBy   string    // 阻止卸载的已挂载的依赖条目，只在变化时输出
+}
+
+// runReaper 实现 reaper 命令
+func runReaper(cmd *cobra.Command, args []string) error {
+    if reaperInterval <= 0 {
+        return fmt.Errorf("--interval must be positive")
+    }
+
+    // 启动时加载一次配置，输出配置的警告，并确认 reaper 能在无人值守时卸载共享
+    cfg, err := loadConfig()
+    if err != nil {
+        return err
+    }
+    if err := configureBackend(cfg); err != nil {
+        return err
+    }
+    if err := checkUnattendedUnmount(); err != nil {
+        return fmt.Errorf("reaper cannot unmount shares: %w", err)
+    }
+
+    ctx := cmd.Context()
+    shares := make(map[string]*idleShare)
+    reaperLog("Checking shares with idle_timeout every %s", reaperInterval)
+    for {
+        if err := reapIdle(ctx, cfg, shares); err != nil {
+            reaperLog("Warning: %v", err)
+        }
+        cfg = nil
+
+        select {
+        case <-time.After(reaperInterval):
+        case <-ctx.Done():
+            reaperLog("Stopped")
+            return nil
+        }
+    }
+}
+
+// reapIdle 检查一轮所有设置了 idle_timeout 的挂载，卸载空闲时间超过 idle_timeout 的共享
+// cfg 为 nil 时重新加载配置，修改 idle_timeout 后不需要重启 reaper；重新加载时不再重复输出配置的警告
+func reapIdle(ctx context.Context, cfg *config.Config, shares map[string]*idleShare) error {
+    if cfg == nil {
+        var err error
+        if cfg, err = readConfig(); err != nil {
+            return err
+        }
+        if err := configureBackend(cfg); err != nil {
+            return err
+        }
+    }
+    statuses, err := mount.RefreshAllStatus(cfg)
+    if err != nil {
+        return fmt.Errorf("failed to refresh mount status: %w", err)
+    }
+    sources := make(map[*config.MountEntry]string)
+    for i := range cfg.Mounts {
+        sources[&cfg.Mounts[i]] = statuses[i].Source
+    }
+
+    // 没有统计时只根据占用挂载点的进程判断
+    activity, err := mount.ReadActivity()
+    if err != nil {
+        reaperLog("Warning: failed to read cifs statistics: %v", err)
+    }
+
+    // 依赖它的条目先检查，同一轮中可以先后卸载
+    entries, err := cfg.MountOrder(cfg.All())
+    if err != nil {
+        return err
+    }
+    slices.Reverse(entries)
+
+    now := time.Now()
+    watched := make(map[string]bool)
+    for _, entry := range entries {
+        if entry.IdleTimeout == 0 || !entry.IsMounted {
+            continue
+        }
+        path := entry.ActualMountPath
+        watched[path] = true
+
+        requests, _ := activity.Requests(sources[entry])
+        share := shares[path]
+        if share == nil || share.source != sources[entry] {
+            shares[path] = &idleShare{source: sources[entry], requests: requests, lastUsed: now}
+            continue
+        }
+        if requests != share.requests {
+            share.requests = requests
+            share.lastUsed = now
+            continue
+        }
+
+        holders, err := mount.FindHolders(path)
+        if err != nil {
+            reaperLog("%s: failed to find the processes using it: %v", entry.Name, err)
+            continue
+        }
+        if len(holders) > 0 {
+            share.lastUsed = now
+            continue
+        }
+
+        idle := now.Sub(share.lastUsed)
+        if idle < entry.IdleTimeout {
+            continue
+        }
+        if dependent := mountedDependent(cfg, entry); dependent != nil {
+            if share.heldBy != dependent.Name {
+                reaperLog("%s: idle for %s, but %s depends on it and is still mounted", entry.Name, idle.Round(time.Second), dependent.Name)
+                share.heldBy = dependent.Name
+            }
+            continue
+        }
+
+        reaperLog("%s: idle for %s, unmounting", entry.Name, idle.Round(time.Second))
+        if err := umountEntry(ctx, cfg, entry, mount.UnmountOptions{}, &entryLog{direct: true}); err != nil {
+            // 下次在又一个 idle_timeout 之后重试
+            reaperLog("%s: failed to unmount: %v", entry.Name, err)
+            share.lastUsed = now
+            continue
+        }
+        entry.IsMounted = false
+        delete(shares, path)
+    }
+
+    // 不再挂载或不再设置 idle_timeout 的挂载重新挂载后重新计时
+    for path := range shares {
+        if !watched[path] {
+            delete(shares, path)
+        }
+    }
+    return nil
+}
+
+// mountedDependent 返回依赖该条目且仍然挂载的条目，没有时返回 nil
+func mountedDependent(cfg *config.Config, entry *config.MountEntry) *config.MountEntry {
+    for i := range cfg.Mounts {
+        other := &cfg.Mounts[i]
+        if other.IsMounted && slices.Contains(cfg.Dependencies(other), entry) {
+            return other
+        }
+    }
+    return nil
+}
+
+// reaperLog 输出带时间的日志行
+func reaperLog(format string, a ...any) {
+    fmt.Printf("%s: %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, a...))
+}
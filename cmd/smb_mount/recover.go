@@ -0,0 +1,128 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "time"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/hsldymq/smb_mount/internal/mount"
+    "github.com/hsldymq/smb_mount/internal/network"
+    "github.com/spf13/cobra"
+)
+
+// defaultRecoverWait 是等待服务器恢复可达的默认最长时间
+const defaultRecoverWait = 5 * time.Minute
+
+var recoverWait time.Duration
+
+var recoverCmd = &cobra.Command{
+    Use:   "recover [name|@tag|pattern...]",
+    Short: "恢复失去响应的挂载",
+    Long: `检查已挂载的共享是否响应。服务器不可达时 cifs 挂载仍然存在，访问它的进程会一直阻塞。
+对失去响应的挂载执行延迟卸载（MNT_DETACH），等待服务器恢复可达后重新挂载。
+未指定共享时检查所有已挂载的共享。`,
+    RunE: runRecover,
+}
+
+func init() {
+    recoverCmd.Flags().DurationVar(&recoverWait, "wait", defaultRecoverWait, "等待服务器恢复可达的最长时间")
+}
+
+// runRecover 实现恢复命令
+func runRecover(cmd *cobra.Command, args []string) error {
+    cfg, err := loadConfig()
+    if err != nil {
+        return err
+    }
+    if err := configureBackend(cfg); err != nil {
+        return err
+    }
+    if _, err := mount.RefreshAllStatus(cfg); err != nil {
+        fmt.Fprintf(os.Stderr, "Warning: failed to refresh mount status: %v\n", err)
+    }
+
+    entries := cfg.All()
+    if len(args) > 0 {
+        if entries, err = cfg.Select(args); err != nil {
+            return err
+        }
+    }
+
+    mount.CheckAllHealth(cfg, cfg.GetProbeTimeout())
+
+    // 只恢复失去响应的挂载
+    var stale []*config.MountEntry
+    for _, entry := range entries {
+        if entry.IsStale {
+            stale = append(stale, entry)
+            continue
+        }
+        // 只报告明确指定的共享
+        if len(args) == 0 {
+            continue
+        }
+        if entry.IsMounted {
+            fmt.Printf("Skipping %s: the mount is responding\n", entry.Name)
+        } else {
+            fmt.Fprintf(os.Stderr, "Skipping %s: not mounted\n", entry.Name)
+        }
+    }
+    if len(stale) == 0 {
+        fmt.Println("No stale mounts found")
+        return nil
+    }
+
+    // 在开始卸载前提示输入密码，避免卸载后因无法挂载而失去共享
+    var pending []*config.MountEntry
+    var failCount int
+    for _, entry := range stale {
+        if err := prepareMountEntry(entry, &entryLog{direct: true}); err != nil {
+            fmt.Fprintf(os.Stderr, "%s: failed to prepare: %v\n\n", entry.Name, err)
+            failCount++
+            continue
+        }
+        pending = append(pending, entry)
+    }
+
+    fmt.Printf("Recovering %d stale share(s)...\n\n", len(pending))
+    ctx := cmd.Context()
+    successCount, failed := runBatch(pending, len(pending), func(entry *config.MountEntry, log *entryLog) error {
+        return recoverEntry(ctx, cfg, entry, log)
+    })
+    failCount += failed
+
+    fmt.Println("==========================================")
+    fmt.Printf("Recover complete: %d succeeded, %d failed\n", successCount, failCount)
+    fmt.Println("==========================================")
+
+    if failCount > 0 {
+        return fmt.Errorf("%d share(s) could not be recovered", failCount)
+    }
+    return nil
+}
+
+// recoverEntry 延迟卸载失去响应的挂载，等待服务器可达后重新挂载
+func recoverEntry(ctx context.Context, cfg *config.Config, entry *config.MountEntry, log *entryLog) error {
+    // 延迟卸载不会阻塞在失去响应的挂载上
+    log.Printf("  Mount is not responding, detaching it\n")
+    if err := umountEntry(ctx, cfg, entry, mount.UnmountOptions{Lazy: true}, log); err != nil {
+        return err
+    }
+    entry.IsMounted = false
+    entry.IsStale = false
+
+    // 经 SSH 隧道连接的服务器由挂载时启动的隧道检查
+    if entry.Tunnel == nil {
+        log.Printf("  Waiting up to %s for the server to be reachable...\n", recoverWait)
+        start := time.Now()
+        result, err := network.WaitReachable(ctx, entry.Addresses(), entry.GetSMBPort(), cfg.GetProbeTimeout(), recoverWait)
+        if err != nil {
+            return fmt.Errorf("server did not become reachable: %w", err)
+        }
+        log.Printf("  Server is reachable at %s after %s\n", result.Address(), time.Since(start).Round(time.Second))
+    }
+
+    return mountEntry(ctx, cfg, entry, log)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/hsldymq/smb_mount/internal/interaction"
+    "github.com/hsldymq/smb_mount/internal/mount"
+    "github.com/spf13/cobra"
+)
+
+var (
+    remountOptions string
+    remountYes     bool
+)
+
+var remountCmd = &cobra.Command{
+    Use:   "remount <name> [-o options]",
+    Short: "以新的选项重新挂载共享",
+    Long: `以新的挂载选项重新挂载已挂载的共享，例如在只读和读写之间切换。
+选项为配置中的 options 加上 -o 指定的选项，-o 中的同名选项会覆盖配置。
+首先尝试 MS_REMOUNT，已打开的文件不受影响；cifs 驱动不支持在线修改这些选项时，
+经确认后通过卸载再挂载的方式应用。最后根据挂载表检查选项是否生效。`,
+    Args: cobra.ExactArgs(1),
+    RunE: runRemount,
+}
+
+func init() {
+    remountCmd.Flags().StringVarP(&remountOptions, "options", "o", "", "以逗号分隔的挂载选项，例如 ro 或 rw,cache=none")
+    remountCmd.Flags().BoolVarP(&remountYes, "yes", "y", false, "需要时不经确认直接卸载再挂载")
+}
+
+// runRemount 实现重新挂载命令
+func runRemount(cmd *cobra.Command, args []string) error {
+    cfg, err := loadConfig()
+    if err != nil {
+        return err
+    }
+    if err := configureBackend(cfg); err != nil {
+        return err
+    }
+    if _, err := mount.RefreshAllStatus(cfg); err != nil {
+        fmt.Fprintf(os.Stderr, "Warning: failed to refresh mount status: %v\n", err)
+    }
+
+    entries, err := cfg.Select(args)
+    if err != nil {
+        return err
+    }
+    if len(entries) != 1 {
+        return fmt.Errorf("'%s' matches %d shares, remount one share at a time", args[0], len(entries))
+    }
+    entry := entries[0]
+    if entry.ForeignMount != "" {
+        return fmt.Errorf("%s is a mount point of %s, not of %s", entry.ActualMountPath, entry.ForeignMount, entry.Name)
+    }
+    if !entry.IsMounted {
+        return fmt.Errorf("%s is not mounted", entry.Name)
+    }
+
+    options := mount.MergeOptions(entry.Options, mount.ParseOptions(remountOptions))
+    if len(options) == 0 {
+        return fmt.Errorf("no options to apply, set options in the config or pass -o")
+    }
+
+    fmt.Printf("Remounting %s with options: %s\n", entry.Name, strings.Join(options, ","))
+
+    ctx := cmd.Context()
+    err = remountEntry(ctx, cfg, entry, options)
+    if err == nil {
+        missing, verifyErr := verifyOptions(entry, options)
+        if verifyErr != nil {
+            return verifyErr
+        }
+        if len(missing) == 0 {
+            fmt.Println("Successfully remounted")
+            return nil
+        }
+        fmt.Printf("Options not applied by the driver: %s\n", strings.Join(missing, ","))
+    } else {
+        fmt.Fprintf(os.Stderr, "Remount failed: %s\n", firstLine(err))
+    }
+
+    // 驱动不支持在线修改时，卸载后用新选项重新挂载
+    if !remountYes {
+        if !interaction.IsTerminal() {
+            return fmt.Errorf("cannot apply the options to the live mount, run with --yes to unmount and mount again")
+        }
+        ok, err := interaction.Confirm("Unmount and mount again with the new options? Files open on the share will be interrupted.")
+        if err != nil {
+            return err
+        }
+        if !ok {
+            return fmt.Errorf("remount cancelled")
+        }
+    }
+
+    log := &entryLog{direct: true}
+    if err := prepareMountEntry(entry, log); err != nil {
+        return fmt.Errorf("failed to prepare: %w", err)
+    }
+
+    if err := umountEntry(ctx, cfg, entry, mount.UnmountOptions{}, log); err != nil {
+        return err
+    }
+    entry.IsMounted = false
+    entry.Options = options
+    if err := mountEntry(ctx, cfg, entry, log); err != nil {
+        return err
+    }
+
+    missing, err := verifyOptions(entry, options)
+    if err != nil {
+        return err
+    }
+    if len(missing) > 0 {
+        return fmt.Errorf("mounted, but these options are not in effect: %s", strings.Join(missing, ","))
+    }
+    return nil
+}
+
+// remountEntry 以新选项执行 MS_REMOUNT，需要时使用 sudo 重试
+func remountEntry(ctx context.Context, cfg *config.Config, entry *config.MountEntry, options []string) error {
+    limit := entry.GetTimeout(cfg.Timeout)
+    ctx, cancel := context.WithTimeout(ctx, limit)
+    defer cancel()
+
+    err := mount.Remount(ctx, entry.ActualMountPath, options)
+    if err == nil || ctx.Err() != nil || !interaction.NeedsPrivilege() {
+        return describeCtxErr(ctx, limit, err)
+    }
+    if sudoErr := checkSudoBackend(err); sudoErr != nil {
+        return sudoErr
+    }
+
+    fmt.Println("Privilege escalation required...")
+    cmd := mount.BuildRemountCommand(entry.ActualMountPath, options)
+    var output bytes.Buffer
+    cmd.Stdout = &output
+    cmd.Stderr = &output
+    if err := interaction.RunWithSudo(ctx, cmd); err != nil {
+        return describeCtxErr(ctx, limit, mount.CommandError("remount with sudo", err, output.Bytes()))
+    }
+    return nil
+}
+
+// verifyOptions 从挂载表中读取挂载选项，返回未生效的选项
+func verifyOptions(entry *config.MountEntry, options []string) ([]string, error) {
+    info, err := mount.GetMountInfo(entry.ActualMountPath)
+    if err != nil {
+        return nil, fmt.Errorf("failed to verify mount options: %w", err)
+    }
+    return mount.MissingOptions(info, options), nil
+}
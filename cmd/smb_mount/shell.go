@@ -0,0 +1,233 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "os/exec"
+    "os/user"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "syscall"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/hsldymq/smb_mount/internal/interaction"
+    "github.com/hsldymq/smb_mount/internal/mount"
+    "github.com/hsldymq/smb_mount/internal/state"
+    "github.com/spf13/cobra"
+)
+
+// sessionCommand 是在私有挂载命名空间中运行 shell 的内部命令，以 root 身份运行
+const sessionCommand = "__shell"
+
+var shellCmd = &cobra.Command{
+    Use:   "shell <name>",
+    Short: "在私有挂载命名空间中挂载共享并启动 shell",
+    Long: `创建新的挂载命名空间，在其中挂载共享并启动 $SHELL，工作目录为挂载路径。
+挂载只在这个 shell 及其子进程中可见，其他会话（包括 smb_mount list）看不到。
+shell 退出后共享随命名空间一起消失。创建命名空间需要 root 权限，必要时通过 sudo 执行，
+shell 仍以当前用户身份运行。`,
+    Args: cobra.ExactArgs(1),
+    RunE: runShell,
+}
+
+var (
+    sessionUID   int
+    sessionGID   int
+    sessionShell string
+)
+
+// sessionCmd 是 shell 命令在新的挂载命名空间中执行的内部命令
+var sessionCmd = &cobra.Command{
+    Use:    sessionCommand + " <name>",
+    Hidden: true,
+    Args:   cobra.ExactArgs(1),
+    RunE:   runSession,
+}
+
+func init() {
+    sessionCmd.Flags().IntVar(&sessionUID, "uid", -1, "运行 shell 的用户 ID（必需）")
+    sessionCmd.Flags().IntVar(&sessionGID, "gid", -1, "运行 shell 的组 ID（必需）")
+    sessionCmd.Flags().StringVar(&sessionShell, "shell", "/bin/sh", "要启动的 shell")
+}
+
+// runShell 实现 shell 命令，以 root 身份启动内部的会话命令
+func runShell(cmd *cobra.Command, args []string) error {
+    cfg, err := loadConfig()
+    if err != nil {
+        return err
+    }
+    entries, err := cfg.Select(args)
+    if err != nil {
+        return err
+    }
+    if len(entries) != 1 {
+        return fmt.Errorf("'%s' matches %d shares, a session holds one share", args[0], len(entries))
+    }
+
+    exe, err := os.Executable()
+    if err != nil {
+        return fmt.Errorf("failed to locate smb_mount: %w", err)
+    }
+    configFile, err := filepath.Abs(resolveConfigPath())
+    if err != nil {
+        return fmt.Errorf("failed to resolve config path: %w", err)
+    }
+    shell := os.Getenv("SHELL")
+    if shell == "" {
+        shell = "/bin/sh"
+    }
+
+    sessionArgs := []string{
+        sessionCommand,
+        "--config", configFile,
+        "--uid", strconv.Itoa(os.Getuid()),
+        "--gid", strconv.Itoa(os.Getgid()),
+        "--shell", shell,
+    }
+    if backend != "" {
+        sessionArgs = append(sessionArgs, "--backend", backend)
+    }
+    if timeout > 0 {
+        sessionArgs = append(sessionArgs, "--timeout", timeout.String())
+    }
+    if strictEnv {
+        sessionArgs = append(sessionArgs, "--strict-env")
+    }
+    sessionArgs = append(sessionArgs, "--", entries[0].Name)
+
+    // 会话使用当前用户的状态目录；HOME 用于展开配置中的 ~
+    env := append(os.Environ(), "XDG_STATE_HOME="+filepath.Dir(state.Dir()))
+
+    // 创建挂载命名空间需要 root 权限
+    // sudo 会清除环境变量，保留会话加载配置需要的变量
+    var c *exec.Cmd
+    if interaction.IsRoot() {
+        c = exec.Command(exe, sessionArgs...)
+    } else {
+        if !interaction.HasSudo() {
+            return fmt.Errorf("a private mount namespace requires root, but sudo is not available")
+        }
+        keep := append([]string{"HOME", "XDG_STATE_HOME"}, cfg.EnvNames...)
+        c = exec.Command("sudo", append([]string{"--preserve-env=" + strings.Join(keep, ","), "--", exe}, sessionArgs...)...)
+    }
+    c.Env = env
+
+    // The session reports its own errors, only pass on its exit code
+    code, err := runCommand(c)
+    if err != nil {
+        return err
+    }
+    if code != 0 {
+        cmd.SilenceErrors = true
+        cmd.SilenceUsage = true
+        return &exitCodeError{code: code}
+    }
+    return nil
+}
+
+// runSession 进入私有挂载命名空间，挂载共享后运行 shell，shell 退出后卸载
+// 所有操作都在进入命名空间的 goroutine 中执行，参见 mount.EnterPrivateNamespace
+func runSession(cmd *cobra.Command, args []string) error {
+    if sessionUID < 0 || sessionGID < 0 {
+        return fmt.Errorf("--uid and --gid are required")
+    }
+
+    // Prepare the shell first, a session that cannot run it as the user must not mount anything
+    shell := exec.Command(sessionShell)
+    if err := dropPrivileges(shell, sessionUID, sessionGID); err != nil {
+        return err
+    }
+
+    if err := mount.EnterPrivateNamespace(); err != nil {
+        return err
+    }
+    // Records and logs go to the user's state directory, keep them writable by the user
+    state.SetOwner(sessionUID, sessionGID)
+
+    cfg, err := loadConfig()
+    if err != nil {
+        return err
+    }
+    if err := configureBackend(cfg); err != nil {
+        return err
+    }
+    if _, err := mount.RefreshAllStatus(cfg); err != nil {
+        fmt.Fprintf(os.Stderr, "Warning: failed to refresh mount status: %v\n", err)
+    }
+
+    entry, ok := cfg.FindByName(args[0])
+    if !ok {
+        return fmt.Errorf("mount entry '%s' not found", args[0])
+    }
+    // A mount made outside the session is visible everywhere
+    if entry.IsMounted || entry.ForeignMount != "" {
+        return fmt.Errorf("%s is already mounted, unmount it before using it in a private session", entry.ActualMountPath)
+    }
+
+    // The share belongs to the user the shell runs as, not to root
+    entry.Owner = &config.Owner{UID: sessionUID, GID: sessionGID}
+
+    log := &entryLog{direct: true}
+    if err := prepareMountEntry(entry, log); err != nil {
+        return fmt.Errorf("failed to prepare: %w", err)
+    }
+
+    ctx := cmd.Context()
+    if err := mountEntry(ctx, cfg, entry, log); err != nil {
+        return err
+    }
+
+    shell.Dir = entry.ActualMountPath
+    shell.Env = append(shell.Env, pathEnvName(entry.Name)+"="+entry.ActualMountPath, "SMB_MOUNT_SESSION="+entry.Name)
+
+    log.Printf("Private session for %s, the share is unmounted when the shell exits\n", entry.Name)
+    code, err := runCommand(shell)
+
+    // The mount disappears with the namespace, unmounting also stops a tunnel and removes created directories
+    log.Printf("Unmounting %s\n", entry.Name)
+    if uerr := umountEntry(context.WithoutCancel(ctx), cfg, entry, mount.UnmountOptions{}, log); uerr != nil {
+        fmt.Fprintf(os.Stderr, "Warning: failed to unmount %s: %v\n", entry.Name, uerr)
+    }
+
+    if err != nil {
+        return err
+    }
+    if code != 0 {
+        cmd.SilenceErrors = true
+        cmd.SilenceUsage = true
+        return &exitCodeError{code: code}
+    }
+    return nil
+}
+
+// dropPrivileges 设置命令的环境，并让命令以指定用户身份运行，设置该用户的 HOME、USER 和 LOGNAME
+// 无法完整切换到该用户时返回错误，此时命令不能运行
+func dropPrivileges(c *exec.Cmd, uid, gid int) error {
+    c.Env = os.Environ()
+    if uid == os.Getuid() {
+        return nil
+    }
+
+    u, err := user.LookupId(strconv.Itoa(uid))
+    if err != nil {
+        return fmt.Errorf("failed to look up user %d: %w", uid, err)
+    }
+    groups, err := u.GroupIds()
+    if err != nil {
+        return fmt.Errorf("failed to look up groups of %s: %w", u.Username, err)
+    }
+
+    credential := &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+    for _, g := range groups {
+        id, err := strconv.ParseUint(g, 10, 32)
+        if err != nil {
+            return fmt.Errorf("invalid group ID %q of %s", g, u.Username)
+        }
+        credential.Groups = append(credential.Groups, uint32(id))
+    }
+    c.SysProcAttr = &syscall.SysProcAttr{Credential: credential}
+    c.Env = append(c.Env, "HOME="+u.HomeDir, "USER="+u.Username, "LOGNAME="+u.Username)
+    return nil
+}
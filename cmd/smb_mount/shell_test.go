@@ -0,0 +1,49 @@
+package main
+
+import (
+    "os"
+    "os/exec"
+    "os/user"
+    "slices"
+    "testing"
+)
+
+func TestDropPrivileges(t *testing.T) {
+    c := exec.Command("true")
+    if err := dropPrivileges(c, 4242424, 4242424); err == nil {
+        t.Fatal("dropPrivileges should fail for an unknown user")
+    }
+    if c.SysProcAttr != nil {
+        t.Error("credentials must not be set when the user cannot be looked up")
+    }
+
+    if os.Getuid() != 0 {
+        t.Skip("switching to another user requires root")
+    }
+    u, err := user.LookupId("65534")
+    if err != nil {
+        t.Skip("no user with ID 65534")
+    }
+    c = exec.Command("true")
+    if err := dropPrivileges(c, 65534, 65534); err != nil {
+        t.Fatal(err)
+    }
+    if c.SysProcAttr == nil || c.SysProcAttr.Credential == nil || c.SysProcAttr.Credential.Uid != 65534 {
+        t.Errorf("credentials not set: %+v", c.SysProcAttr)
+    }
+    if !slices.Contains(c.Env, "USER="+u.Username) || !slices.Contains(c.Env, "HOME="+u.HomeDir) {
+        t.Errorf("environment of %s not set", u.Username)
+    }
+}
+
+func TestRunSessionRequiresUser(t *testing.T) {
+    env := newTestEnv(t, "a")
+
+    _, _, err := run(t, runSession, "a")
+    if err == nil || err.Error() != "--uid and --gid are required" {
+        t.Errorf("err = %v, want --uid and --gid are required", err)
+    }
+    if len(env.fake.Calls) != 0 {
+        t.Errorf("unexpected backend calls: %v", env.fake.Calls)
+    }
+}
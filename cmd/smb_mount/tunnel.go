@@ -0,0 +1,68 @@
+package main
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/hsldymq/smb_mount/internal/state"
+    "github.com/hsldymq/smb_mount/internal/tunnel"
+    "github.com/spf13/cobra"
+)
+
+// tunnelCmd 是运行 SSH 隧道监督进程的内部命令，由挂载流程在后台启动
+var tunnelCmd = &cobra.Command{
+    Use:                tunnel.SuperviseCommand + " command [args...]",
+    Hidden:             true,
+    DisableFlagParsing: true,
+    SilenceUsage:       true, // The log only needs the error
+    SilenceErrors:      true,
+    Args:               cobra.MinimumNArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        return tunnel.Supervise(cmd.Context(), args)
+    },
+}
+
+// startTunnel 为条目启动 SSH 隧道，挂载将经由隧道的本地端口连接服务器
+func startTunnel(ctx context.Context, entry *config.MountEntry, log *entryLog) (*tunnel.Tunnel, error) {
+    t, err := tunnel.Start(ctx, entry)
+    if err != nil {
+        return nil, err
+    }
+    log.Printf("  Tunnel: 127.0.0.1:%d -> %s:%d via %s (pid %d)\n",
+        t.LocalPort, entry.SMBAddr, entry.GetSMBPort(), describeTunnel(entry.Tunnel), t.PID)
+    entry.TunnelPort = t.LocalPort
+    return t, nil
+}
+
+// recordTunnel 在状态文件中记录挂载使用的隧道，卸载时据此停止隧道
+func recordTunnel(entry *config.MountEntry, t *tunnel.Tunnel) error {
+    return state.Update(func(s *state.State) error {
+        s.Ensure(entry.ActualMountPath, entry.Name).Tunnel = &state.Tunnel{PID: t.PID, LocalPort: t.LocalPort}
+        return nil
+    })
+}
+
+// stopTunnel 停止状态文件中为挂载点记录的隧道
+func stopTunnel(entry *config.MountEntry, log *entryLog) error {
+    return state.Update(func(s *state.State) error {
+        m := s.Get(entry.ActualMountPath)
+        if m == nil || m.Tunnel == nil {
+            return nil
+        }
+        if err := tunnel.Stop(m.Tunnel.PID); err != nil {
+            return err
+        }
+        log.Printf("  Stopped tunnel (pid %d)\n", m.Tunnel.PID)
+        m.Tunnel = nil
+        return nil
+    })
+}
+
+// describeTunnel 返回条目隧道的简短描述
+func describeTunnel(t *config.Tunnel) string {
+    if t.SSHUser != "" {
+        return fmt.Sprintf("%s@%s", t.SSHUser, t.SSHHost)
+    }
+    return t.SSHHost
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+    "fmt"
+    "net"
+    "strconv"
+    "time"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/hsldymq/smb_mount/internal/network"
+    "github.com/spf13/cobra"
+)
+
+var wakeNoWait bool
+
+var wakeCmd = &cobra.Command{
+    Use:   "wake <name|@tag|pattern...>",
+    Short: "通过 Wake-on-LAN 唤醒服务器",
+    Long: `向条目的 wol 设置中配置的 MAC 地址发送 Wake-on-LAN 唤醒包，
+然后等待 SMB 端口可以连接，最长等待 wol.wait 指定的时间。
+使用 --no-wait 只发送唤醒包。`,
+    Args: cobra.MinimumNArgs(1),
+    RunE: runWake,
+}
+
+func init() {
+    wakeCmd.Flags().BoolVar(&wakeNoWait, "no-wait", false, "发送唤醒包后不等待服务器启动")
+}
+
+// runWake 实现唤醒命令
+func runWake(cmd *cobra.Command, args []string) error {
+    cfg, err := loadConfig()
+    if err != nil {
+        return err
+    }
+
+    entries, err := cfg.Select(args)
+    if err != nil {
+        return err
+    }
+
+    // 所有服务器同时唤醒和等待
+    ctx := cmd.Context()
+    succeeded, failed := runBatch(entries, len(entries), func(entry *config.MountEntry, log *entryLog) error {
+        wol := entry.WakeOnLAN
+        if wol == nil {
+            return fmt.Errorf("no wol settings configured")
+        }
+
+        if err := network.SendMagicPacket(wol.MAC, wol.GetBroadcast(), wol.GetPort()); err != nil {
+            return err
+        }
+        log.Printf("  Sent Wake-on-LAN packet to %s via %s\n", wol.MAC, net.JoinHostPort(wol.GetBroadcast(), strconv.Itoa(wol.GetPort())))
+        if wakeNoWait {
+            return nil
+        }
+
+        log.Printf("  Waiting up to %s for the server to come up...\n", wol.GetWait())
+        start := time.Now()
+        result, err := network.WaitReachable(ctx, entry.Addresses(), entry.GetSMBPort(), cfg.GetProbeTimeout(), wol.GetWait())
+        if err != nil {
+            return err
+        }
+        log.Printf("  Server is up at %s after %s\n", result.Address(), time.Since(start).Round(time.Second))
+        return nil
+    })
+
+    if failed > 0 {
+        return fmt.Errorf("%d of %d server(s) did not wake up", failed, succeeded+failed)
+    }
+    return nil
+}
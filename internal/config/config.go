@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
@@ -15,9 +16,20 @@ func init() {
 	validate = validator.New()
 }
 
+// LoadOptions 控制配置加载行为
+type LoadOptions struct {
+	// StrictEnv 为 true 时，引用未设置的环境变量将导致加载失败
+	StrictEnv bool
+}
+
 // Load 从指定路径加载配置
 // 如果路径为空，则使用默认路径
 func Load(path string) (*Config, error) {
+	return LoadWithOptions(path, LoadOptions{})
+}
+
+// LoadWithOptions 使用指定选项从路径加载配置
+func LoadWithOptions(path string, opts LoadOptions) (*Config, error) {
 	if path == "" {
 		path = DefaultConfigPath()
 	}
@@ -27,50 +39,111 @@ func Load(path string) (*Config, error) {
 		return nil, &ConfigError{Path: path, Err: fmt.Errorf("config file not found")}
 	}
 
-	// Create new viper instance
-	v := viper.New()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("failed to read config: %w", err)}
+	}
 
-	// Set config path and file
-	v.SetConfigFile(path)
+	// Parse YAML document, keeping node positions for error reporting
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("failed to read config: %w", err)}
+	}
+	pos := documentPositions{root: doc.Content[0]}
 
-	// Read config file
-	if err := v.ReadInConfig(); err != nil {
+	// Upgrade older config layouts in memory
+	fromVersion, _, err := migrateDocument(doc)
+	if err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+
+	var raw map[string]any
+	if err := doc.Decode(&raw); err != nil {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("failed to read config: %w", err)}
+	}
+
+	// Fill in retry settings that are not set, so that explicit zero values are kept
+	applyRetryDefaults(raw)
+
+	// Create new viper instance
+	v := viper.New()
+	if err := v.MergeConfigMap(raw); err != nil {
 		return nil, &ConfigError{Path: path, Err: fmt.Errorf("failed to read config: %w", err)}
 	}
 
+	// Check for keys that do not belong to the config structure
+	issues := checkUnknownKeys(v.AllSettings(), reflect.TypeOf(Config{}), "")
+
 	// Unmarshal config
 	cfg := &Config{}
 	if err := v.Unmarshal(cfg); err != nil {
 		return nil, &ConfigError{Path: path, Err: fmt.Errorf("failed to parse config: %w", err)}
 	}
 
+	// Expand environment variable references
+	if err := cfg.ExpandEnv(opts.StrictEnv); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+
 	// Validate config
 	if err := validate.Struct(cfg); err != nil {
-		return nil, &ConfigError{Path: path, Err: fmt.Errorf("config validation failed: %w", err)}
+		issues = append(issues, translateValidationErrors(err)...)
+		issues = append(issues, cfg.checkEntries(false)...)
+	} else {
+		// Apply defaults and resolve paths
+		if err := cfg.Normalize(); err != nil {
+			return nil, &ConfigError{Path: path, Err: fmt.Errorf("failed to normalize config: %w", err)}
+		}
+		issues = append(issues, cfg.checkEntries(true)...)
 	}
 
-	// Apply defaults and resolve paths
-	if err := cfg.Normalize(); err != nil {
-		return nil, &ConfigError{Path: path, Err: fmt.Errorf("failed to normalize config: %w", err)}
+	locateIssues(pos, issues)
+	if len(issues) > 0 {
+		return nil, &ConfigError{Path: path, Err: &ValidationError{File: path, Issues: issues}}
 	}
+	cfg.SourceVersion = fromVersion
 
 	return cfg, nil
 }
 
+// applyRetryDefaults 为全局和各条目的 retry 中未设置的字段填入 RetryPolicy 的 default 标签值
+// 在解码前处理，显式写出的零值（例如 jitter: 0）不会被当作未设置
+func applyRetryDefaults(raw map[string]any) {
+	policies := []any{raw["retry"]}
+	if mounts, ok := raw["mounts"].([]any); ok {
+		for _, m := range mounts {
+			if entry, ok := m.(map[string]any); ok {
+				policies = append(policies, entry["retry"])
+			}
+		}
+	}
+
+	t := reflect.TypeOf(RetryPolicy{})
+	for _, p := range policies {
+		policy, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+		for i := 0; i < t.NumField(); i++ {
+			key := t.Field(i).Tag.Get("mapstructure")
+			def, ok := t.Field(i).Tag.Lookup("default")
+			if _, set := policy[key]; ok && !set {
+				policy[key] = def
+			}
+		}
+	}
+}
+
 // Normalize 应用默认值并解析路径
 func (c *Config) Normalize() error {
-	// Expand ~ in base_dir
-	baseDir := c.BaseDir
-	if len(baseDir) > 0 && baseDir[0] == '~' {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to expand ~ in base_dir: %w", err)
-		}
-		baseDir = home + baseDir[1:]
+	// Expand ~ or ~user in base_dir
+	baseDir, err := ExpandHome(c.BaseDir)
+	if err != nil {
+		return fmt.Errorf("failed to expand ~ in base_dir: %w", err)
 	}
 
 	// Convert to absolute path
-	baseDir, err := filepath.Abs(baseDir)
+	baseDir, err = filepath.Abs(baseDir)
 	if err != nil {
 		return fmt.Errorf("failed to resolve absolute path for base_dir: %w", err)
 	}
@@ -89,34 +162,32 @@ func (c *Config) Normalize() error {
 // Normalize 解析单个条目的挂载路径
 func (m *MountEntry) Normalize(baseDir string) error {
 	// Resolve mount path
-	mountPath := m.MountDirPath
+	mountPath := m.Target
 	if mountPath == "" {
-		// Use mount_dir_name or name
-		dirName := m.MountDirName
-		if dirName == "" {
-			dirName = m.Name
-		}
-		mountPath = filepath.Join(baseDir, dirName)
-	} else {
-		// Expand ~ if present
-		if len(mountPath) > 0 && mountPath[0] == '~' {
-			home, err := os.UserHomeDir()
-			if err != nil {
-				return fmt.Errorf("failed to expand ~ in mount_dir_path: %w", err)
-			}
-			mountPath = home + mountPath[1:]
-		}
+		mountPath = m.Name
+	}
 
-		// Convert to absolute path
-		var err error
-		mountPath, err = filepath.Abs(mountPath)
-		if err != nil {
-			return fmt.Errorf("failed to resolve absolute path for mount_dir_path: %w", err)
-		}
+	// Expand ~ or ~user if present
+	mountPath, err := ExpandHome(mountPath)
+	if err != nil {
+		return fmt.Errorf("failed to expand ~ in target: %w", err)
 	}
 
-	m.ActualMountPath = mountPath
+	// Relative targets live under base_dir
+	if !filepath.IsAbs(mountPath) {
+		mountPath = filepath.Join(baseDir, mountPath)
+	}
+
+	m.ActualMountPath = filepath.Clean(mountPath)
 	m.mountPathResolved = true
+
+	if m.Tunnel != nil && m.Tunnel.IdentityFile != "" {
+		identity, err := ExpandHome(m.Tunnel.IdentityFile)
+		if err != nil {
+			return fmt.Errorf("failed to expand ~ in identity_file: %w", err)
+		}
+		m.Tunnel.IdentityFile = identity
+	}
 	return nil
 }
 
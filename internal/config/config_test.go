@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadString 将 YAML 写入临时文件并加载
+func loadString(t *testing.T, yaml string) (*Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path)
+}
+
+func TestLoadRetryDefaults(t *testing.T) {
+	defaults := RetryPolicy{Attempts: 3, InitialDelay: time.Second, MaxDelay: 30 * time.Second, Jitter: 0.2}
+
+	tests := []struct {
+		name   string
+		global string
+		entry  string
+		want   RetryPolicy
+	}{
+		{name: "no retry", want: RetryPolicy{Attempts: 1}},
+		{name: "empty global", global: "retry: {}", want: defaults},
+		{name: "explicit zero jitter", global: "retry: {jitter: 0}", want: RetryPolicy{Attempts: 3, InitialDelay: time.Second, MaxDelay: 30 * time.Second}},
+		{name: "explicit zero delays", global: "retry: {initial_delay: 0s, max_delay: 0s}", want: RetryPolicy{Attempts: 3, Jitter: 0.2}},
+		{name: "entry overrides global", global: "retry: {attempts: 5}", entry: ", retry: {jitter: 0}", want: RetryPolicy{Attempts: 3, InitialDelay: time.Second, MaxDelay: 30 * time.Second}},
+		{name: "entry without retry", global: "retry: {attempts: 5}", want: RetryPolicy{Attempts: 5, InitialDelay: time.Second, MaxDelay: 30 * time.Second, Jitter: 0.2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadString(t, "version: 2\nbase_dir: "+t.TempDir()+"\n"+tt.global+"\nmounts:\n"+
+				"  - {name: nas, smb_addr: nas, share_name: media, username: u, password: p"+tt.entry+"}\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cfg.Mounts[0].GetRetryPolicy(cfg.Retry); got != tt.want {
+				t.Errorf("GetRetryPolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRetryZeroAttempts(t *testing.T) {
+	_, err := loadString(t, "version: 2\nbase_dir: "+t.TempDir()+"\nretry: {attempts: 0}\nmounts:\n"+
+		"  - {name: nas, smb_addr: nas, share_name: media, username: u, password: p}\n")
+	if err == nil || !strings.Contains(err.Error(), "retry.attempts: must be at least 1") {
+		t.Errorf("attempts: 0 should fail validation, got %v", err)
+	}
+}
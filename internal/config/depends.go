@@ -0,0 +1,79 @@
+package config
+
+import (
+	"slices"
+	"strings"
+)
+
+// CycleError 表示条目之间存在循环依赖
+type CycleError struct {
+	Names []string // 构成循环的条目名称，首尾相同
+}
+
+func (e *CycleError) Error() string {
+	return "dependency cycle: " + strings.Join(e.Names, " -> ")
+}
+
+// Dependencies 返回条目的前置条目：depends_on 中列出的条目，以及挂载路径包含此条目挂载路径的条目
+// 挂载路径的包含关系要求配置已经过 Normalize；不存在的名称被忽略，由配置校验报告
+func (c *Config) Dependencies(entry *MountEntry) []*MountEntry {
+	var deps []*MountEntry
+	for i := range c.Mounts {
+		other := &c.Mounts[i]
+		if other == entry {
+			continue
+		}
+		if slices.Contains(entry.DependsOn, other.Name) || isNestedPath(entry.ActualMountPath, other.ActualMountPath) {
+			deps = append(deps, other)
+		}
+	}
+	return deps
+}
+
+// MountOrder 按依赖关系排序条目，前置条目排在依赖它的条目之前，其余条目保持原有顺序
+// 只考虑 entries 之间的依赖；存在循环依赖时返回 *CycleError
+func (c *Config) MountOrder(entries []*MountEntry) ([]*MountEntry, error) {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[*MountEntry]int, len(entries))
+	for _, entry := range entries {
+		state[entry] = 0
+	}
+
+	order := make([]*MountEntry, 0, len(entries))
+	var path []string
+	var visit func(entry *MountEntry) error
+	visit = func(entry *MountEntry) error {
+		switch state[entry] {
+		case visited:
+			return nil
+		case visiting:
+			start := slices.Index(path, entry.Name)
+			return &CycleError{Names: append(slices.Clone(path[start:]), entry.Name)}
+		}
+
+		state[entry] = visiting
+		path = append(path, entry.Name)
+		for _, dep := range c.Dependencies(entry) {
+			if _, selected := state[dep]; !selected {
+				continue
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[entry] = visited
+		order = append(order, entry)
+		return nil
+	}
+
+	for _, entry := range entries {
+		if err := visit(entry); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// expander 负责展开配置值中的 ${VAR} 和 ${VAR:-default} 引用
+type expander struct {
+	strict  bool
+	lookup  func(string) (string, bool)
+	missing []string
+	names   []string // 引用过的变量名，按首次出现的顺序
+}
+
+// expandStrings 递归展开结构体中所有字符串字段里的环境变量引用
+// field 为当前值在 YAML 中的路径，用于错误信息
+func (e *expander) expandStrings(v reflect.Value, field string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return e.expandStrings(v.Elem(), field)
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := yamlName(f)
+			if name == "-" {
+				continue
+			}
+			if f.Tag.Get("expand") == "exact" {
+				if err := e.expandExact(v.Field(i), joinField(field, name)); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := e.expandStrings(v.Field(i), joinField(field, name)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := e.expandStrings(v.Index(i), fmt.Sprintf("%s[%d]", field, i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.String:
+		expanded, err := e.expand(v.String(), field)
+		if err != nil {
+			return err
+		}
+		v.SetString(expanded)
+	}
+
+	return nil
+}
+
+// expandExact 只在整个值恰好是一个 ${VAR} 或 ${VAR:-default} 引用时展开，其他值按原样保留
+// 用于带有 expand:"exact" 标签的字段，例如密码中的 $ 通常是字面量；整个值为 $${...} 时表示字面量 ${...}
+func (e *expander) expandExact(v reflect.Value, field string) error {
+	s := v.String()
+	if literal, ok := strings.CutPrefix(s, "$${"); ok && strings.HasSuffix(literal, "}") {
+		v.SetString(s[1:])
+		return nil
+	}
+	ref, ok := strings.CutPrefix(s, "${")
+	if !ok {
+		return nil
+	}
+	ref, ok = strings.CutSuffix(ref, "}")
+	if !ok || strings.ContainsRune(ref, '}') {
+		return nil
+	}
+	if name, _, _ := strings.Cut(ref, ":-"); !isValidEnvName(name) {
+		return nil
+	}
+
+	value, err := e.resolve(ref, field)
+	if err != nil {
+		return err
+	}
+	v.SetString(value)
+	return nil
+}
+
+// expand 展开单个字符串中的变量引用
+// 支持 ${VAR}、${VAR:-default}，$$ 表示字面量 $
+func (e *expander) expand(s string, field string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+
+		case '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("%s: unterminated variable reference in %q", field, s)
+			}
+			ref := s[i+2 : i+2+end]
+			value, err := e.resolve(ref, field)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i += end + 2
+
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String(), nil
+}
+
+// resolve 解析单个变量引用（花括号内的部分）
+func (e *expander) resolve(ref string, field string) (string, error) {
+	name, def, hasDefault := strings.Cut(ref, ":-")
+	if !isValidEnvName(name) {
+		return "", fmt.Errorf("%s: invalid variable name %q", field, name)
+	}
+
+	if !slices.Contains(e.names, name) {
+		e.names = append(e.names, name)
+	}
+	value, ok := e.lookup(name)
+	if ok && value != "" {
+		return value, nil
+	}
+	if hasDefault {
+		return def, nil
+	}
+	if !ok && e.strict {
+		e.missing = append(e.missing, fmt.Sprintf("%s (referenced by %s)", name, field))
+	}
+	return value, nil
+}
+
+// ExpandEnv 展开配置中所有字符串字段的环境变量引用，引用过的变量名记录在 EnvNames 中
+// strict 为 true 时，引用未设置且没有默认值的变量将返回错误
+func (c *Config) ExpandEnv(strict bool) error {
+	e := &expander{strict: strict, lookup: os.LookupEnv}
+	if err := e.expandStrings(reflect.ValueOf(c), ""); err != nil {
+		return err
+	}
+	c.EnvNames = e.names
+	if len(e.missing) > 0 {
+		return fmt.Errorf("environment variable(s) not set: %s", strings.Join(e.missing, ", "))
+	}
+	return nil
+}
+
+// ExpandHome 展开路径开头的 ~ 或 ~user
+func ExpandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+
+	name, rest, _ := strings.Cut(path[1:], "/")
+
+	var home string
+	if name == "" {
+		h, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		home = h
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up user %q: %w", name, err)
+		}
+		home = u.HomeDir
+	}
+
+	return filepath.Join(home, rest), nil
+}
+
+// yamlName 返回结构体字段在 YAML 中的名称
+func yamlName(f reflect.StructField) string {
+	tag := f.Tag.Get("yaml")
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// joinField 拼接 YAML 字段路径
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// isValidEnvName 检查环境变量名是否合法
+func isValidEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
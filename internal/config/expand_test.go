@@ -0,0 +1,62 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExpandEnvPassword(t *testing.T) {
+	t.Setenv("NAS_PASSWORD", "secret")
+	t.Setenv("NAS_HOST", "10.0.0.5")
+
+	tests := []struct {
+		password string
+		want     string
+	}{
+		{password: "${NAS_PASSWORD}", want: "secret"},
+		{password: "${UNSET_PASSWORD:-fallback}", want: "fallback"},
+		{password: "pa$$w0rd", want: "pa$$w0rd"},
+		{password: "x${y", want: "x${y"},
+		{password: "pre${NAS_PASSWORD}", want: "pre${NAS_PASSWORD}"},
+		{password: "${NAS_PASSWORD}${NAS_HOST}", want: "${NAS_PASSWORD}${NAS_HOST}"},
+		{password: "${not valid}", want: "${not valid}"},
+		{password: "$${NAS_PASSWORD}", want: "${NAS_PASSWORD}"},
+		{password: "$", want: "$"},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{Mounts: []MountEntry{{SMBAddr: "${NAS_HOST}", Password: tt.password}}}
+		if err := cfg.ExpandEnv(false); err != nil {
+			t.Errorf("ExpandEnv with password %q: %v", tt.password, err)
+			continue
+		}
+		if got := cfg.Mounts[0].Password; got != tt.want {
+			t.Errorf("password %q expanded to %q, want %q", tt.password, got, tt.want)
+		}
+		if got := cfg.Mounts[0].SMBAddr; got != "10.0.0.5" {
+			t.Errorf("smb_addr expanded to %q, want 10.0.0.5", got)
+		}
+	}
+}
+
+func TestExpandEnvStrictPassword(t *testing.T) {
+	cfg := &Config{Mounts: []MountEntry{{Password: "${UNSET_PASSWORD}"}}}
+	if err := cfg.ExpandEnv(true); err == nil {
+		t.Error("expected an error for an unset variable in strict mode")
+	}
+}
+
+func TestExpandEnvNames(t *testing.T) {
+	t.Setenv("NAS_HOST", "10.0.0.5")
+
+	cfg := &Config{BaseDir: "${MNT_ROOT:-/mnt}", Mounts: []MountEntry{
+		{SMBAddr: "${NAS_HOST}", Password: "${NAS_PASSWORD}"},
+		{SMBAddr: "${NAS_HOST}", Password: "pa$$w0rd"},
+	}}
+	if err := cfg.ExpandEnv(false); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"MNT_ROOT", "NAS_HOST", "NAS_PASSWORD"}; !slices.Equal(cfg.EnvNames, want) {
+		t.Errorf("EnvNames = %v, want %v", cfg.EnvNames, want)
+	}
+}
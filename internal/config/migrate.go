@@ -0,0 +1,268 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// CurrentVersion 是当前配置格式的版本号
+const CurrentVersion = 2
+
+// Migration 将配置从 From 版本升级到 From+1 版本
+// 迁移直接作用于 YAML 节点树，以便改写文件时保留注释和键的顺序
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(root *yaml.Node) error
+}
+
+// migrations 按版本顺序注册的迁移
+var migrations = []Migration{
+	{
+		From:        1,
+		Description: "merge mount_dir_name and mount_dir_path into target",
+		Apply:       migrateMountDirToTarget,
+	},
+}
+
+// MigrationResult 描述对配置文件执行迁移的结果
+type MigrationResult struct {
+	FromVersion int
+	Applied     []Migration
+	Original    []byte
+	Migrated    []byte
+	BackupPath  string // 仅在写入文件时设置
+}
+
+// Diff 返回迁移前后内容的统一差异格式
+func (r *MigrationResult) Diff(path string) string {
+	return unifiedDiff(path+".orig", path, string(r.Original), string(r.Migrated))
+}
+
+// parseDocument 解析 YAML 文档并返回根映射节点
+func parseDocument(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("config file is empty")
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config root must be a mapping")
+	}
+	return &doc, nil
+}
+
+// documentVersion 返回文档声明的版本，未声明时视为版本 1
+func documentVersion(root *yaml.Node) (int, error) {
+	node := mappingValue(root, "version")
+	if node == nil {
+		return 1, nil
+	}
+	version, err := strconv.Atoi(node.Value)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid config version %q", node.Value)
+	}
+	if version > CurrentVersion {
+		return 0, fmt.Errorf("config version %d is newer than the supported version %d", version, CurrentVersion)
+	}
+	return version, nil
+}
+
+// migrateDocument 将文档升级到当前版本，返回原版本和已应用的迁移
+func migrateDocument(doc *yaml.Node) (int, []Migration, error) {
+	root := doc.Content[0]
+
+	from, err := documentVersion(root)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var applied []Migration
+	for version := from; version < CurrentVersion; version++ {
+		m, ok := findMigration(version)
+		if !ok {
+			return from, applied, fmt.Errorf("no migration registered from version %d", version)
+		}
+		if err := m.Apply(root); err != nil {
+			return from, applied, fmt.Errorf("migration from version %d failed: %w", version, err)
+		}
+		applied = append(applied, m)
+	}
+
+	if len(applied) > 0 {
+		setMappingValue(root, "version", strconv.Itoa(CurrentVersion), "!!int")
+	}
+	return from, applied, nil
+}
+
+// findMigration 查找从指定版本开始的迁移
+func findMigration(from int) (Migration, bool) {
+	for _, m := range migrations {
+		if m.From == from {
+			return m, true
+		}
+	}
+	return Migration{}, false
+}
+
+// MigrateFile 读取配置文件并计算迁移结果
+// write 为 true 且有迁移被应用时，原文件会备份为 .bak 后被改写
+func MigrateFile(path string, write bool) (*MigrationResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	from, applied, err := migrateDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MigrationResult{FromVersion: from, Applied: applied, Original: data, Migrated: data}
+	if len(applied) == 0 {
+		return result, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	result.Migrated = buf.Bytes()
+
+	if !write {
+		return result, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat config: %w", err)
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", path, from)
+	if err := os.WriteFile(backup, data, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	result.BackupPath = backup
+
+	if err := os.WriteFile(path, result.Migrated, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write migrated config: %w", err)
+	}
+
+	return result, nil
+}
+
+// migrateMountDirToTarget 版本 1 -> 2：将 mount_dir_name/mount_dir_path 合并为 target
+// mount_dir_path 优先；mount_dir_name 始终相对于 base_dir
+// 版本 1 中相对的 mount_dir_path 相对于当前目录，而相对的 target 相对于 base_dir，因此迁移时转换为绝对路径
+func migrateMountDirToTarget(root *yaml.Node) error {
+	mounts := mappingValue(root, "mounts")
+	if mounts == nil || mounts.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	for _, entry := range mounts.Content {
+		if entry.Kind != yaml.MappingNode {
+			continue
+		}
+
+		pathKey := mappingKey(entry, "mount_dir_path")
+		nameKey := mappingKey(entry, "mount_dir_name")
+
+		switch {
+		case pathKey != nil:
+			pathKey.Value = "target"
+			if err := absMountDirPath(mappingValue(entry, "target")); err != nil {
+				return err
+			}
+			if nameKey != nil {
+				removeMappingKey(entry, "mount_dir_name")
+			}
+		case nameKey != nil:
+			nameKey.Value = "target"
+			value := mappingValue(entry, "target")
+			value.Value = strings.TrimLeft(value.Value, "/")
+		}
+	}
+
+	return nil
+}
+
+// absMountDirPath 将相对的 mount_dir_path 转换为基于当前目录的绝对路径
+// 以 ~ 或 $ 开头的值在展开后才能确定，保持不变
+func absMountDirPath(value *yaml.Node) error {
+	path := value.Value
+	if path == "" || filepath.IsAbs(path) || strings.HasPrefix(path, "~") || strings.HasPrefix(path, "$") {
+		return nil
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve mount_dir_path %q: %w", path, err)
+	}
+	value.Value = abs
+	return nil
+}
+
+// mappingKey 返回映射节点中指定键的键节点
+func mappingKey(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+// mappingValue 返回映射节点中指定键的值节点
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue 设置映射节点中的标量值，键不存在时插入到最前面
+func setMappingValue(node *yaml.Node, key, value, tag string) {
+	if v := mappingValue(node, key); v != nil {
+		v.Kind, v.Value, v.Tag, v.Style = yaml.ScalarNode, value, tag, 0
+		return
+	}
+
+	k := &yaml.Node{Kind: yaml.ScalarNode, Value: key, Tag: "!!str"}
+	v := &yaml.Node{Kind: yaml.ScalarNode, Value: value, Tag: tag}
+
+	// Keep the head comment (e.g. the schema modeline) at the top of the file
+	if len(node.Content) > 0 {
+		k.HeadComment, node.Content[0].HeadComment = node.Content[0].HeadComment, ""
+	}
+	node.Content = append([]*yaml.Node{k, v}, node.Content...)
+}
+
+// removeMappingKey 从映射节点中删除指定键
+func removeMappingKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
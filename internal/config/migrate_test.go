@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+)
+
+func TestMigrateMountDirToTarget(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		entry string
+		want  string
+	}{
+		{name: "absolute path", entry: "{mount_dir_path: /srv/nas}", want: "/srv/nas"},
+		{name: "relative path", entry: "{mount_dir_path: rel/dir}", want: filepath.Join(cwd, "rel/dir")},
+		{name: "home directory", entry: "{mount_dir_path: ~/nas}", want: "~/nas"},
+		{name: "variable", entry: "{mount_dir_path: '${NAS_DIR}/nas'}", want: "${NAS_DIR}/nas"},
+		{name: "path wins over name", entry: "{mount_dir_name: nas, mount_dir_path: /srv/nas}", want: "/srv/nas"},
+		{name: "name", entry: "{mount_dir_name: /nas/media}", want: "nas/media"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parseDocument([]byte("mounts:\n  - " + tt.entry + "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := migrateMountDirToTarget(doc.Content[0]); err != nil {
+				t.Fatal(err)
+			}
+
+			entry := mappingValue(doc.Content[0], "mounts").Content[0]
+			target := mappingValue(entry, "target")
+			if target == nil {
+				t.Fatal("target not set")
+			}
+			if target.Value != tt.want {
+				t.Errorf("target = %q, want %q", target.Value, tt.want)
+			}
+			for _, key := range []string{"mount_dir_name", "mount_dir_path"} {
+				if mappingValue(entry, key) != nil {
+					out, _ := yaml.Marshal(entry)
+					t.Errorf("%s left in entry:\n%s", key, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaID 是生成的 JSON Schema 的标识符
+const SchemaID = "https://github.com/hsldymq/smb_mount/smb_mount_config.schema.json"
+
+// durationPattern 匹配 Go 的时间长度格式，例如 30s、1m30s
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// macPattern 匹配以冒号或短横线分隔的 MAC 地址
+const macPattern = `^[0-9A-Fa-f]{2}([:-][0-9A-Fa-f]{2}){5}$`
+
+// envReferencePattern 匹配包含环境变量引用的值，这类值在展开前无法按格式校验
+const envReferencePattern = `\$\{[^}]+\}`
+
+// Schema 根据配置结构生成 JSON Schema
+// 字段名取自 yaml 标签，约束取自 validate 标签，默认值和描述取自 default 和 desc 标签
+func Schema() map[string]any {
+	schema := schemaFor(reflect.TypeOf(Config{}))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["$id"] = SchemaID
+	schema["title"] = "smb_mount configuration"
+	return schema
+}
+
+// SchemaJSON 返回格式化后的 JSON Schema
+func SchemaJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(Schema(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// DefaultSchemaPath 返回与配置文件相邻的 schema 文件路径
+func DefaultSchemaPath(configPath string) string {
+	dir := filepath.Dir(configPath)
+	name := strings.TrimSuffix(filepath.Base(configPath), filepath.Ext(configPath))
+	return filepath.Join(dir, name+".schema.json")
+}
+
+// schemaFor 返回单个类型的 schema
+func schemaFor(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return map[string]any{"type": "string", "pattern": durationPattern}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]any)
+		var required []string
+
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := yamlName(f)
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+
+			prop := schemaFor(f.Type)
+			applyFieldTags(prop, f)
+			properties[name] = prop
+
+			if hasRule(f.Tag.Get("validate"), "required") {
+				required = append(required, name)
+			}
+		}
+
+		schema := map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+
+	case reflect.Slice, reflect.Array:
+		return map[string]any{
+			"type":  "array",
+			"items": schemaFor(t.Elem()),
+		}
+
+	case reflect.String:
+		return map[string]any{"type": "string"}
+
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	}
+
+	return map[string]any{}
+}
+
+// applyFieldTags 将字段标签中的约束、默认值和描述写入 schema
+func applyFieldTags(prop map[string]any, f reflect.StructField) {
+	if desc := f.Tag.Get("desc"); desc != "" {
+		prop["description"] = desc
+	}
+
+	if def, ok := f.Tag.Lookup("default"); ok {
+		prop["default"] = parseDefault(def, f.Type)
+	}
+
+	applyRules(prop, strings.Split(f.Tag.Get("validate"), ","))
+}
+
+// applyRules 将 validate 规则写入 schema，dive 之后的规则作用于数组元素
+func applyRules(prop map[string]any, rules []string) {
+	isArray := prop["type"] == "array"
+	for i, rule := range rules {
+		key, param, _ := strings.Cut(rule, "=")
+		switch key {
+		case "dive":
+			if items, ok := prop["items"].(map[string]any); ok && isArray {
+				applyRules(items, rules[i+1:])
+			}
+			return
+		case "min", "max":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			switch {
+			case isArray && key == "min":
+				prop["minItems"] = n
+			case isArray && key == "max":
+				prop["maxItems"] = n
+			case prop["type"] == "string" && key == "min":
+				prop["minLength"] = n
+			case prop["type"] == "string" && key == "max":
+				prop["maxLength"] = n
+			case key == "min":
+				prop["minimum"] = n
+			default:
+				prop["maximum"] = n
+			}
+
+		case "oneof":
+			prop["enum"] = strings.Fields(param)
+
+		case "ip":
+			prop["anyOf"] = []any{
+				map[string]any{"format": "ipv4"},
+				map[string]any{"format": "ipv6"},
+				map[string]any{"pattern": envReferencePattern},
+			}
+
+		case "mac":
+			prop["anyOf"] = []any{
+				map[string]any{"pattern": macPattern},
+				map[string]any{"pattern": envReferencePattern},
+			}
+
+		case "hostname_rfc1123|ip":
+			prop["anyOf"] = []any{
+				map[string]any{"format": "hostname"},
+				map[string]any{"format": "ipv4"},
+				map[string]any{"format": "ipv6"},
+				map[string]any{"pattern": envReferencePattern},
+			}
+		}
+	}
+}
+
+// parseDefault 将 default 标签的值转换为字段对应的 JSON 类型
+func parseDefault(def string, t reflect.Type) any {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(def, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(def, 64); err == nil {
+			return f
+		}
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(def); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// hasRule 检查 validate 标签是否包含作用于字段本身的指定规则（dive 之后的规则作用于元素）
+func hasRule(tag, rule string) bool {
+	for _, r := range strings.Split(tag, ",") {
+		if r == "dive" {
+			return false
+		}
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// TagPrefix 是按标签选择条目的选择器前缀
+const TagPrefix = "@"
+
+// Select 根据选择器返回匹配的挂载条目，按配置中的顺序排列且不重复
+// 选择器可以是条目名称、@tag 或 glob 模式（如 nas-*）
+// 名称不存在、标签或模式没有匹配任何条目时返回错误
+func (c *Config) Select(selectors []string) ([]*MountEntry, error) {
+	matched := make([]bool, len(c.Mounts))
+
+	for _, sel := range selectors {
+		found := false
+		for i := range c.Mounts {
+			ok, err := c.Mounts[i].Matches(sel)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				matched[i] = true
+				found = true
+			}
+		}
+
+		if !found {
+			switch {
+			case strings.HasPrefix(sel, TagPrefix):
+				return nil, fmt.Errorf("no mount entries tagged '%s'", strings.TrimPrefix(sel, TagPrefix))
+			case isGlobPattern(sel):
+				return nil, fmt.Errorf("no mount entries match '%s'", sel)
+			default:
+				return nil, fmt.Errorf("mount entry '%s' not found", sel)
+			}
+		}
+	}
+
+	var entries []*MountEntry
+	for i := range c.Mounts {
+		if matched[i] {
+			entries = append(entries, &c.Mounts[i])
+		}
+	}
+	return entries, nil
+}
+
+// All 返回所有挂载条目
+func (c *Config) All() []*MountEntry {
+	entries := make([]*MountEntry, len(c.Mounts))
+	for i := range c.Mounts {
+		entries[i] = &c.Mounts[i]
+	}
+	return entries
+}
+
+// Matches 检查条目是否匹配单个选择器
+func (m *MountEntry) Matches(selector string) (bool, error) {
+	if tag, ok := strings.CutPrefix(selector, TagPrefix); ok {
+		return m.HasTag(tag), nil
+	}
+
+	if isGlobPattern(selector) {
+		ok, err := path.Match(selector, m.Name)
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern '%s': %w", selector, err)
+		}
+		return ok, nil
+	}
+
+	return m.Name == selector, nil
+}
+
+// HasTag 返回条目是否带有指定标签
+func (m *MountEntry) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// isGlobPattern 检查选择器是否包含 glob 元字符
+func isGlobPattern(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
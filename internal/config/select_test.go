@@ -0,0 +1,106 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// selectConfig 返回用于测试选择器的配置
+func selectConfig() *Config {
+	return &Config{Mounts: []MountEntry{
+		{Name: "nas-media", Tags: []string{"home", "media"}},
+		{Name: "work-docs", Tags: []string{"work"}},
+		{Name: "nas-backup", Tags: []string{"home"}},
+		{Name: "scratch"},
+	}}
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name      string
+		selectors []string
+		want      []string
+		wantErr   string
+	}{
+		{name: "name", selectors: []string{"work-docs"}, want: []string{"work-docs"}},
+		{name: "tag", selectors: []string{"@home"}, want: []string{"nas-media", "nas-backup"}},
+		{name: "glob", selectors: []string{"nas-*"}, want: []string{"nas-media", "nas-backup"}},
+		{name: "single character glob", selectors: []string{"nas-?edia"}, want: []string{"nas-media"}},
+		{name: "config order", selectors: []string{"scratch", "nas-backup", "nas-media"}, want: []string{"nas-media", "nas-backup", "scratch"}},
+		{name: "overlapping selectors", selectors: []string{"@home", "nas-*", "nas-media"}, want: []string{"nas-media", "nas-backup"}},
+		{name: "mixed", selectors: []string{"@work", "scratch"}, want: []string{"work-docs", "scratch"}},
+		{name: "unknown name", selectors: []string{"missing"}, wantErr: "mount entry 'missing' not found"},
+		{name: "unknown tag", selectors: []string{"@missing"}, wantErr: "no mount entries tagged 'missing'"},
+		{name: "glob without match", selectors: []string{"office-*"}, wantErr: "no mount entries match 'office-*'"},
+		{name: "one selector without match", selectors: []string{"nas-*", "missing"}, wantErr: "mount entry 'missing' not found"},
+		{name: "invalid pattern", selectors: []string{"nas-[a"}, wantErr: "invalid pattern 'nas-[a'"},
+		{name: "no selectors", selectors: nil, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := selectConfig().Select(tt.selectors)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Select(%q) error = %v, want %q", tt.selectors, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Select(%q) error = %v", tt.selectors, err)
+			}
+
+			var names []string
+			for _, entry := range entries {
+				names = append(names, entry.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("Select(%q) = %v, want %v", tt.selectors, names, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectReturnsConfigEntries(t *testing.T) {
+	cfg := selectConfig()
+	entries, err := cfg.Select([]string{"scratch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries[0] != &cfg.Mounts[3] {
+		t.Error("Select should return pointers into cfg.Mounts")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	entry := MountEntry{Name: "nas-media", Tags: []string{"media"}}
+	tests := []struct {
+		selector string
+		want     bool
+		wantErr  bool
+	}{
+		{selector: "nas-media", want: true},
+		{selector: "nas", want: false},
+		{selector: "NAS-MEDIA", want: false},
+		{selector: "@media", want: true},
+		{selector: "@nas-media", want: false},
+		{selector: "@", want: false},
+		{selector: "nas-*", want: true},
+		{selector: "*-media", want: true},
+		{selector: "[mn]as-media", want: true},
+		{selector: "*/media", want: false},
+		{selector: "[", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := entry.Matches(tt.selector)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Matches(%q) error = %v, wantErr %v", tt.selector, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SchemaModeline 返回让 yaml-language-server 关联 schema 的注释行
+func SchemaModeline(schemaPath string) string {
+	return fmt.Sprintf("# yaml-language-server: $schema=%s\n", schemaPath)
+}
+
+// configTemplate 是 config init 生成的初始配置内容
+const configTemplate = `
+version: 2
+
+# Base directory for mount points
+base_dir: ~/smb
+
+mounts:
+  - name: nas
+    smb_addr: 192.168.1.10
+    # smb_port: 445
+    share_name: shared
+    username: your_username
+    # password: ${NAS_PASSWORD}   # prompted for when omitted
+    # target: /mnt/nas            # relative to base_dir unless absolute, defaults to name
+`
+
+// WriteTemplate 在指定路径写入初始配置文件和对应的 JSON Schema
+// 配置文件以 0600 权限创建，已存在的配置文件只有在 overwrite 为 true 时才会被覆盖
+func WriteTemplate(path string, overwrite bool) (string, error) {
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return "", fmt.Errorf("config file already exists: %s", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	schemaPath := DefaultSchemaPath(path)
+	schema, err := SchemaJSON()
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(schemaPath, schema, 0644); err != nil {
+		return "", fmt.Errorf("failed to write schema: %w", err)
+	}
+
+	content := SchemaModeline(schemaPath) + configTemplate
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return "", fmt.Errorf("failed to write config: %w", err)
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(path, 0600); err != nil {
+		return "", fmt.Errorf("failed to set config file permissions: %w", err)
+	}
+
+	return schemaPath, nil
+}
@@ -1,48 +1,107 @@
 package config
 
+import (
+    "os"
+    "path/filepath"
+    "slices"
+    "time"
+)
+
+// DefaultTimeout 是未配置 timeout 时单次挂载或卸载操作的超时时间
+const DefaultTimeout = 60 * time.Second
+
+// DefaultProbeTimeout 是未配置 probe_timeout 时连通性探测的超时时间
+const DefaultProbeTimeout = 3 * time.Second
+
 // Config 主配置结构
 type Config struct {
-    BaseDir string       `yaml:"base_dir" mapstructure:"base_dir" validate:"required"`
-    Mounts  []MountEntry `yaml:"mounts" mapstructure:"mounts" validate:"required,min=1"`
+    Version      int           `yaml:"version" mapstructure:"version" validate:"omitempty,min=1" default:"2" desc:"Config format version"`
+    Backend      string        `yaml:"backend" mapstructure:"backend" validate:"omitempty,oneof=exec syscall" default:"exec" desc:"Mount backend: exec runs mount.cifs, syscall calls mount(2) directly"`
+    Timeout      time.Duration `yaml:"timeout" mapstructure:"timeout" validate:"omitempty,min=1s" default:"60s" desc:"Default time limit for a single mount or unmount, e.g. 30s"`
+    Retry        *RetryPolicy  `yaml:"retry" mapstructure:"retry" desc:"Retry policy for transient network failures"`
+    ProbeTimeout time.Duration `yaml:"probe_timeout" mapstructure:"probe_timeout" validate:"omitempty,min=100ms" default:"3s" desc:"Time limit for the TCP reachability check before mounting"`
+    Cleanup      bool          `yaml:"cleanup" mapstructure:"cleanup" default:"false" desc:"Remove mount directories created by smb_mount after unmounting"`
+    BaseDir      string        `yaml:"base_dir" mapstructure:"base_dir" validate:"required" desc:"Base directory for mount points"`
+    Mounts       []MountEntry  `yaml:"mounts" mapstructure:"mounts" validate:"required,min=1,dive" desc:"SMB shares to manage"`
+
+    // 运行时字段（不从配置加载）
+    SourceVersion int      `yaml:"-" mapstructure:"-"` // 迁移前文件声明的版本
+    EnvNames      []string `yaml:"-" mapstructure:"-"` // 配置中引用的环境变量名
 }
 
 // MountEntry 单个 SMB 挂载配置
 type MountEntry struct {
-    Name         string `yaml:"name" mapstructure:"name" validate:"required"`
-    SMBAddr      string `yaml:"smb_addr" mapstructure:"smb_addr" validate:"required"`
-    SMBPort      int    `yaml:"smb_port" mapstructure:"smb_port" validate:"min=1,max=65535"`
-    ShareName    string `yaml:"share_name" mapstructure:"share_name" validate:"required"`
-    Username     string `yaml:"username" mapstructure:"username" validate:"required"`
-    Password     string `yaml:"password" mapstructure:"password"`
-    MountDirName string `yaml:"mount_dir_name" mapstructure:"mount_dir_name"`
-    MountDirPath string `yaml:"mount_dir_path" mapstructure:"mount_dir_path"`
+    Name             string        `yaml:"name" mapstructure:"name" validate:"required" desc:"Unique identifier for this mount"`
+    SMBAddr          string        `yaml:"smb_addr" mapstructure:"smb_addr" validate:"required,hostname_rfc1123|ip" desc:"SMB server hostname or IP address"`
+    FallbackAddrs    []string      `yaml:"fallback_addrs" mapstructure:"fallback_addrs" validate:"dive,hostname_rfc1123|ip" desc:"Alternative server addresses, tried in order when smb_addr is not reachable"`
+    SMBPort          int           `yaml:"smb_port" mapstructure:"smb_port" validate:"omitempty,min=1,max=65535" default:"445" desc:"SMB server port"`
+    ShareName        string        `yaml:"share_name" mapstructure:"share_name" validate:"required" desc:"Share name on the server"`
+    Username         string        `yaml:"username" mapstructure:"username" validate:"required" desc:"Login username"`
+    Password         string        `yaml:"password" mapstructure:"password" expand:"exact" desc:"Login password, prompted for when empty; only a value that is exactly ${VAR} or ${VAR:-default} is expanded"`
+    Target           string        `yaml:"target" mapstructure:"target" desc:"Mount path; relative paths are resolved under base_dir, defaults to name"`
+    Options          []string      `yaml:"options" mapstructure:"options" validate:"dive,required,excludesall=0x2C" desc:"Extra mount options passed to the cifs driver, e.g. ro or cache=none"`
+    Timeout          time.Duration `yaml:"timeout" mapstructure:"timeout" validate:"omitempty,min=1s" desc:"Time limit for mounting or unmounting this share, overrides the global timeout"`
+    Tags             []string      `yaml:"tags" mapstructure:"tags" validate:"dive,required,excludesall=@*?[" desc:"Tags for selecting several entries at once with @tag"`
+    DependsOn        []string      `yaml:"depends_on" mapstructure:"depends_on" validate:"dive,required" desc:"Names of entries that must be mounted before this one; entries whose mount path contains this one are added automatically"`
+    Retry            *RetryPolicy  `yaml:"retry" mapstructure:"retry" desc:"Retry policy for this share, replaces the global retry policy"`
+    WakeOnLAN        *WakeOnLAN    `yaml:"wol" mapstructure:"wol" desc:"Wake-on-LAN settings for waking the server when it is not reachable"`
+    Tunnel           *Tunnel       `yaml:"tunnel" mapstructure:"tunnel" desc:"SSH tunnel for reaching a server behind a jump host"`
+    Cleanup          *bool         `yaml:"cleanup" mapstructure:"cleanup" desc:"Remove the mount directory after unmounting if smb_mount created it, overrides the global cleanup"`
+    AutoUnmountAfter time.Duration `yaml:"auto_unmount_after" mapstructure:"auto_unmount_after" validate:"omitempty,min=1m" desc:"Unmount this share automatically this long after mounting it"`
+    IdleTimeout      time.Duration `yaml:"idle_timeout" mapstructure:"idle_timeout" validate:"omitempty,min=1m" desc:"Unmount this share after it has not been used for this long; checked by smb_mount reaper"`
 
     // 运行时字段（不从配置加载）
-    ActualMountPath   string `yaml:"-" mapstructure:"-"`
-    IsMounted         bool   `yaml:"-" mapstructure:"-"`
-    mountPathResolved bool   `yaml:"-" mapstructure:"-"`
+    ActualMountPath   string    `yaml:"-" mapstructure:"-"`
+    IsMounted         bool      `yaml:"-" mapstructure:"-"`
+    ForeignMount      string    `yaml:"-" mapstructure:"-"` // 挂载路径上其他文件系统的来源和类型，为空表示没有
+    IsStale           bool      `yaml:"-" mapstructure:"-"` // 已挂载但没有响应，由健康检查设置
+    ExpiresAt         time.Time `yaml:"-" mapstructure:"-"` // 限时挂载的到期时间，零值表示不限时
+    ExpiryStopped     bool      `yaml:"-" mapstructure:"-"` // 到期进程已不在运行，到期时不会自动卸载
+    ActiveAddr        string    `yaml:"-" mapstructure:"-"` // 挂载实际使用的服务器地址
+    TunnelPort        int       `yaml:"-" mapstructure:"-"` // SSH 隧道的本地端口，非 0 时经 127.0.0.1 连接服务器
+    Owner             *Owner    `yaml:"-" mapstructure:"-"` // 挂载后文件和新建目录的属主，为 nil 时为当前用户
+    mountPathResolved bool      `yaml:"-" mapstructure:"-"`
 }
 
 // GetMountPath 返回此条目的实际挂载路径
-// 优先级：mount_dir_path > base_dir + mount_dir_name > base_dir + name
+// 绝对路径的 target 直接使用，否则为 base_dir + (target 或 name)
 func (m *MountEntry) GetMountPath(baseDir string) string {
     if m.mountPathResolved {
         return m.ActualMountPath
     }
 
-    if m.MountDirPath != "" {
-        m.ActualMountPath = m.MountDirPath
+    target := m.Target
+    if target == "" {
+        target = m.Name
+    }
+    if filepath.IsAbs(target) {
+        m.ActualMountPath = target
     } else {
-        dirName := m.MountDirName
-        if dirName == "" {
-            dirName = m.Name
-        }
-        m.ActualMountPath = baseDir + "/" + dirName
+        m.ActualMountPath = filepath.Join(baseDir, target)
     }
     m.mountPathResolved = true
     return m.ActualMountPath
 }
 
+// Addresses 返回按尝试顺序排列的服务器地址：smb_addr 在前，随后是 fallback_addrs
+func (m *MountEntry) Addresses() []string {
+    addrs := []string{m.SMBAddr}
+    for _, addr := range m.FallbackAddrs {
+        if !slices.Contains(addrs, addr) {
+            addrs = append(addrs, addr)
+        }
+    }
+    return addrs
+}
+
+// GetSMBAddr 返回挂载使用的服务器地址，未选定备用地址时为 smb_addr
+func (m *MountEntry) GetSMBAddr() string {
+    if m.ActiveAddr != "" {
+        return m.ActiveAddr
+    }
+    return m.SMBAddr
+}
+
 // GetSMBPort 返回 SMB 端口，如果未设置则默认为 445
 func (m *MountEntry) GetSMBPort() int {
     if m.SMBPort == 0 {
@@ -51,6 +110,118 @@ func (m *MountEntry) GetSMBPort() int {
     return m.SMBPort
 }
 
+// RetryPolicy 挂载遇到临时网络错误时的重试策略
+type RetryPolicy struct {
+    Attempts     int           `yaml:"attempts" mapstructure:"attempts" validate:"min=1" default:"3" desc:"Total number of attempts, including the first one"`
+    InitialDelay time.Duration `yaml:"initial_delay" mapstructure:"initial_delay" default:"1s" desc:"Delay before the first retry, doubled after each attempt"`
+    MaxDelay     time.Duration `yaml:"max_delay" mapstructure:"max_delay" default:"30s" desc:"Upper bound for the delay between attempts"`
+    Jitter       float64       `yaml:"jitter" mapstructure:"jitter" validate:"omitempty,min=0,max=1" default:"0.2" desc:"Random variation applied to each delay, as a fraction of it"`
+}
+
+// GetRetryPolicy 返回此条目的重试策略，未设置的字段在加载配置时已填入默认值
+// 优先级：条目的 retry > 全局 retry；两者都未配置时只尝试一次
+func (m *MountEntry) GetRetryPolicy(global *RetryPolicy) RetryPolicy {
+    policy := m.Retry
+    if policy == nil {
+        policy = global
+    }
+    if policy == nil {
+        return RetryPolicy{Attempts: 1}
+    }
+
+    return *policy
+}
+
+// Wake-on-LAN 的默认值
+const (
+    DefaultWakeBroadcast = "255.255.255.255"
+    DefaultWakePort      = 9
+    DefaultWakeWait      = 60 * time.Second
+)
+
+// WakeOnLAN 服务器不可达时发送唤醒包的设置
+type WakeOnLAN struct {
+    MAC       string        `yaml:"mac" mapstructure:"mac" validate:"required,mac" desc:"MAC address of the server's network interface"`
+    Broadcast string        `yaml:"broadcast" mapstructure:"broadcast" validate:"omitempty,ip" default:"255.255.255.255" desc:"Broadcast address the magic packet is sent to"`
+    Port      int           `yaml:"port" mapstructure:"port" validate:"omitempty,min=1,max=65535" default:"9" desc:"UDP port the magic packet is sent to"`
+    Wait      time.Duration `yaml:"wait" mapstructure:"wait" validate:"omitempty,min=1s" default:"60s" desc:"How long to wait for the server to come up after waking it"`
+}
+
+// GetBroadcast 返回唤醒包的广播地址，未设置时为 DefaultWakeBroadcast
+func (w *WakeOnLAN) GetBroadcast() string {
+    if w.Broadcast == "" {
+        return DefaultWakeBroadcast
+    }
+    return w.Broadcast
+}
+
+// GetPort 返回唤醒包的 UDP 端口，未设置时为 DefaultWakePort
+func (w *WakeOnLAN) GetPort() int {
+    if w.Port == 0 {
+        return DefaultWakePort
+    }
+    return w.Port
+}
+
+// GetWait 返回唤醒后等待服务器启动的时间，未设置时为 DefaultWakeWait
+func (w *WakeOnLAN) GetWait() time.Duration {
+    if w.Wait == 0 {
+        return DefaultWakeWait
+    }
+    return w.Wait
+}
+
+// Tunnel 通过 SSH 跳板机转发 SMB 端口的设置
+type Tunnel struct {
+    SSHHost      string `yaml:"ssh_host" mapstructure:"ssh_host" validate:"required,hostname_rfc1123|ip" desc:"Jump host to connect to with ssh"`
+    SSHPort      int    `yaml:"ssh_port" mapstructure:"ssh_port" validate:"omitempty,min=1,max=65535" default:"22" desc:"SSH port of the jump host"`
+    SSHUser      string `yaml:"ssh_user" mapstructure:"ssh_user" desc:"User to log in to the jump host as, defaults to the ssh configuration"`
+    IdentityFile string `yaml:"identity_file" mapstructure:"identity_file" desc:"Private key used to log in to the jump host"`
+}
+
+// Owner 挂载的属主，以 root 身份替其他用户挂载时使用
+type Owner struct {
+    UID int
+    GID int
+}
+
+// GetOwner 返回挂载后文件的属主，未设置 Owner 时为当前用户
+func (m *MountEntry) GetOwner() (uid, gid int) {
+    if m.Owner != nil {
+        return m.Owner.UID, m.Owner.GID
+    }
+    return os.Getuid(), os.Getgid()
+}
+
+// GetTimeout 返回此条目单次操作的超时时间
+// 优先级：条目的 timeout > 全局 timeout > DefaultTimeout
+func (m *MountEntry) GetTimeout(global time.Duration) time.Duration {
+    if m.Timeout > 0 {
+        return m.Timeout
+    }
+    if global > 0 {
+        return global
+    }
+    return DefaultTimeout
+}
+
+// GetProbeTimeout 返回连通性探测的超时时间，未配置时为 DefaultProbeTimeout
+func (c *Config) GetProbeTimeout() time.Duration {
+    if c.ProbeTimeout > 0 {
+        return c.ProbeTimeout
+    }
+    return DefaultProbeTimeout
+}
+
+// GetCleanup 返回卸载后是否删除 smb_mount 创建的挂载目录
+// 优先级：条目的 cleanup > 全局 cleanup
+func (m *MountEntry) GetCleanup(global bool) bool {
+    if m.Cleanup != nil {
+        return *m.Cleanup
+    }
+    return global
+}
+
 // HasPassword 返回是否配置了密码
 func (m *MountEntry) HasPassword() bool {
     return m.Password != ""
@@ -0,0 +1,394 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"go.yaml.in/yaml/v3"
+)
+
+// Issue 描述配置中的单个问题
+type Issue struct {
+	Field   string // YAML 中的字段路径，例如 mounts[2].smb_addr
+	Line    int    // 行号，未知时为 0
+	Column  int    // 列号，未知时为 0
+	Message string
+}
+
+// String 返回问题的可读描述
+func (i Issue) String() string {
+	if i.Field == "" {
+		return i.Message
+	}
+	return fmt.Sprintf("%s: %s", i.Field, i.Message)
+}
+
+// Location 返回问题在文件中的位置，格式为 file:line:column
+func (i Issue) Location(file string) string {
+	if i.Line == 0 {
+		return file
+	}
+	return fmt.Sprintf("%s:%d:%d", file, i.Line, i.Column)
+}
+
+// ValidationError 配置验证失败，包含所有发现的问题
+type ValidationError struct {
+	File   string
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d problem(s) found", len(e.Issues))
+	for _, issue := range e.Issues {
+		b.WriteString("\n  ")
+		b.WriteString(issue.Location(e.File))
+		b.WriteString(": ")
+		b.WriteString(issue.String())
+	}
+	return b.String()
+}
+
+// validator 使用 YAML 字段名报告错误
+func init() {
+	validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := yamlName(f)
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+}
+
+// documentPositions 保存 YAML 文档的节点树，用于查找字段位置
+type documentPositions struct {
+	root *yaml.Node
+}
+
+// locate 返回字段路径对应的行列号
+// 如果字段本身不存在（例如缺少必填字段），则返回最近的父节点位置
+func (d documentPositions) locate(field string) (int, int) {
+	node := d.root
+	if node == nil {
+		return 0, 0
+	}
+
+	for _, part := range splitField(field) {
+		next := d.child(node, part)
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return node.Line, node.Column
+}
+
+// child 返回节点的子节点，part 可以是映射键或 [index]
+func (d documentPositions) child(node *yaml.Node, part string) *yaml.Node {
+	if strings.HasPrefix(part, "[") {
+		idx, err := strconv.Atoi(strings.Trim(part, "[]"))
+		if err != nil || node.Kind != yaml.SequenceNode || idx >= len(node.Content) {
+			return nil
+		}
+		return node.Content[idx]
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if strings.EqualFold(node.Content[i].Value, part) {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// keyPosition 返回映射键本身的位置，用于报告未知键
+func (d documentPositions) keyPosition(field string) (int, int) {
+	parts := splitField(field)
+	if len(parts) == 0 || d.root == nil {
+		return 0, 0
+	}
+
+	parent := strings.Join(parts[:len(parts)-1], ".")
+	node := d.root
+	for _, part := range splitField(parent) {
+		if node = d.child(node, part); node == nil {
+			return 0, 0
+		}
+	}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if strings.EqualFold(node.Content[i].Value, parts[len(parts)-1]) {
+				return node.Content[i].Line, node.Content[i].Column
+			}
+		}
+	}
+	return node.Line, node.Column
+}
+
+// splitField 将 mounts[2].smb_addr 拆分为 mounts、[2]、smb_addr
+func splitField(field string) []string {
+	var parts []string
+	for _, seg := range strings.Split(field, ".") {
+		if seg == "" {
+			continue
+		}
+		for {
+			idx := strings.IndexByte(seg, '[')
+			if idx < 0 {
+				parts = append(parts, seg)
+				break
+			}
+			if idx > 0 {
+				parts = append(parts, seg[:idx])
+			}
+			end := strings.IndexByte(seg, ']')
+			if end < idx {
+				parts = append(parts, seg[idx:])
+				break
+			}
+			parts = append(parts, seg[idx:end+1])
+			seg = seg[end+1:]
+			if seg == "" {
+				break
+			}
+		}
+	}
+	return parts
+}
+
+// checkUnknownKeys 检查原始配置数据中不属于配置结构的键
+func checkUnknownKeys(raw any, t reflect.Type, field string) []Issue {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var issues []Issue
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := raw.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		known := make(map[string]reflect.StructField)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if name := yamlName(f); f.IsExported() && name != "-" {
+				known[name] = f
+			}
+		}
+
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			f, ok := known[strings.ToLower(k)]
+			if !ok {
+				msg := "unknown key"
+				if s := suggestKey(k, known); s != "" {
+					msg = fmt.Sprintf("unknown key (did you mean %q?)", s)
+				}
+				issues = append(issues, Issue{Field: joinField(field, k), Message: msg})
+				continue
+			}
+			issues = append(issues, checkUnknownKeys(m[k], f.Type, joinField(field, k))...)
+		}
+
+	case reflect.Slice:
+		items, ok := raw.([]any)
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			issues = append(issues, checkUnknownKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", field, i))...)
+		}
+	}
+
+	return issues
+}
+
+// suggestKey 返回与未知键最接近的已知键
+func suggestKey(key string, known map[string]reflect.StructField) string {
+	best, bestDist := "", 3
+	for name := range known {
+		if d := editDistance(strings.ToLower(key), name); d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// editDistance 计算两个字符串的编辑距离
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// translateValidationErrors 将 validator 的错误转换为可读的问题列表
+func translateValidationErrors(err error) []Issue {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return []Issue{{Message: err.Error()}}
+	}
+
+	issues := make([]Issue, 0, len(verrs))
+	for _, fe := range verrs {
+		// Namespace looks like "Config.mounts[2].smb_addr"
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		issues = append(issues, Issue{Field: field, Message: describeFieldError(fe)})
+	}
+	return issues
+}
+
+// describeFieldError 返回单个字段验证错误的描述
+func describeFieldError(fe validator.FieldError) string {
+	isCollection := fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if isCollection {
+			return fmt.Sprintf("must contain at least %s item(s)", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		if isCollection {
+			return fmt.Sprintf("must contain at most %s item(s)", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.Join(strings.Fields(fe.Param()), ", "))
+	case "excludesall":
+		chars := fe.Param()
+		// Characters that would break the tag syntax are written as hex, e.g. 0x2C for ','
+		if hex, ok := strings.CutPrefix(chars, "0x"); ok {
+			if n, err := strconv.ParseUint(hex, 16, 32); err == nil {
+				chars = string(rune(n))
+			}
+		}
+		return fmt.Sprintf("must not contain any of %q", chars)
+	case "hostname_rfc1123|ip":
+		return fmt.Sprintf("%q is not a valid hostname or IP address", fe.Value())
+	case "ip":
+		return fmt.Sprintf("%q is not a valid IP address", fe.Value())
+	case "mac":
+		return fmt.Sprintf("%q is not a valid MAC address", fe.Value())
+	default:
+		return fmt.Sprintf("failed %q validation", fe.Tag())
+	}
+}
+
+// checkEntries 检查条目之间的冲突：重复名称、未知或循环的依赖、重复的挂载路径
+// 挂载路径检查要求配置已经过 Normalize，嵌套的挂载路径按依赖处理
+func (c *Config) checkEntries(checkPaths bool) []Issue {
+	var issues []Issue
+
+	names := make(map[string]int)
+	for i, m := range c.Mounts {
+		if m.Name == "" {
+			continue
+		}
+		if first, ok := names[m.Name]; ok {
+			issues = append(issues, Issue{
+				Field:   fmt.Sprintf("mounts[%d].name", i),
+				Message: fmt.Sprintf("duplicate name %q (first defined in mounts[%d])", m.Name, first),
+			})
+			continue
+		}
+		names[m.Name] = i
+	}
+
+	for i, m := range c.Mounts {
+		for j, dep := range m.DependsOn {
+			field := fmt.Sprintf("mounts[%d].depends_on[%d]", i, j)
+			_, known := names[dep]
+			switch {
+			case dep == m.Name:
+				issues = append(issues, Issue{Field: field, Message: "an entry cannot depend on itself"})
+			case !known && dep != "":
+				issues = append(issues, Issue{Field: field, Message: fmt.Sprintf("unknown entry %q", dep)})
+			}
+		}
+	}
+
+	if !checkPaths {
+		return issues
+	}
+
+	// Cycles may also come from nested mount paths, so they are checked once paths are resolved
+	var cycle *CycleError
+	if _, err := c.MountOrder(c.All()); errors.As(err, &cycle) {
+		issues = append(issues, Issue{
+			Field:   fmt.Sprintf("mounts[%d].depends_on", names[cycle.Names[0]]),
+			Message: cycle.Error(),
+		})
+	}
+
+	for i := range c.Mounts {
+		for j := 0; j < i; j++ {
+			if a, b := c.Mounts[j].ActualMountPath, c.Mounts[i].ActualMountPath; a == b {
+				issues = append(issues, Issue{
+					Field:   fmt.Sprintf("mounts[%d]", i),
+					Message: fmt.Sprintf("mount path %s is already used by mounts[%d] (%s)", b, j, c.Mounts[j].Name),
+				})
+			}
+		}
+	}
+
+	return issues
+}
+
+// isNestedPath 检查 path 是否位于 base 之下
+func isNestedPath(path, base string) bool {
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// locateIssues 为问题填充位置信息并按位置排序
+func locateIssues(pos documentPositions, issues []Issue) {
+	for i := range issues {
+		if strings.HasPrefix(issues[i].Message, "unknown key") {
+			issues[i].Line, issues[i].Column = pos.keyPosition(issues[i].Field)
+		} else {
+			issues[i].Line, issues[i].Column = pos.locate(issues[i].Field)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Column < issues[j].Column
+	})
+}
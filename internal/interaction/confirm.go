@@ -0,0 +1,33 @@
+package interaction
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "strings"
+
+    "golang.org/x/sys/unix"
+)
+
+// IsTerminal 返回标准输入是否为终端
+func IsTerminal() bool {
+    _, err := unix.IoctlGetTermios(int(os.Stdin.Fd()), unix.TCGETS)
+    return err == nil
+}
+
+// Confirm 询问是否继续，输入 y 或 yes 时返回 true，其他输入或直接回车返回 false
+func Confirm(prompt string) (bool, error) {
+    fmt.Printf("%s [y/N] ", prompt)
+
+    answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+    if err != nil && answer == "" {
+        return false, fmt.Errorf("failed to read answer: %w", err)
+    }
+
+    switch strings.ToLower(strings.TrimSpace(answer)) {
+    case "y", "yes":
+        return true, nil
+    default:
+        return false, nil
+    }
+}
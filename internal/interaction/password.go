@@ -2,6 +2,7 @@ package interaction
 
 import (
     "fmt"
+    "os"
     "strings"
 
     tea "github.com/charmbracelet/bubbletea"
@@ -94,13 +95,14 @@ func (m PasswordModel) GetPassword() string {
 }
 
 // PromptPassword 使用 BubbleTea 提示用户输入密码
+// 提示写到标准错误，标准输出被重定向时不会混入其中
 func PromptPassword(promptText string, showAsterisk bool) (string, error) {
     if promptText == "" {
         promptText = "Enter password: "
     }
 
     model := NewPasswordModel(promptText, showAsterisk)
-    program := tea.NewProgram(model)
+    program := tea.NewProgram(model, tea.WithOutput(os.Stderr))
 
     finalModel, err := program.Run()
     if err != nil {
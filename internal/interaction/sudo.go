@@ -1,12 +1,20 @@
 package interaction
 
 import (
+    "context"
     "fmt"
     "os"
     "os/exec"
     "os/user"
+    "sync"
+    "syscall"
+    "time"
 )
 
+// sudoMu 保证同一时间只有一个 sudo 命令在运行，
+// 并行批量操作时密码提示不会交错，后续命令可复用 sudo 的凭据缓存
+var sudoMu sync.Mutex
+
 // IsRoot 检查当前进程是否以 root 身份运行
 func IsRoot() bool {
     currentUser, err := user.Current()
@@ -27,12 +35,29 @@ func NeedsPrivilege() bool {
     return !IsRoot()
 }
 
+// CanSudoWithoutPassword 检查 sudo 能否不询问密码地执行命令
+// 忽略终端中缓存的凭据（sudo -k），用于判断没有终端的后台进程能否使用 sudo
+func CanSudoWithoutPassword(cmd *exec.Cmd) bool {
+    if !HasSudo() {
+        return false
+    }
+    args := append([]string{"-n", "-k", "--", cmd.Path}, cmd.Args[1:]...)
+    check := exec.Command("sudo", args...)
+    return check.Run() == nil
+}
+
+// sudoKillDelay 是 ctx 结束后等待 sudo 转发 SIGTERM 并退出的时间，超时后强制终止
+const sudoKillDelay = 5 * time.Second
+
 // RunWithSudo 使用 sudo 执行命令
-// 用 sudo 包装命令及其参数
-func RunWithSudo(cmd *exec.Cmd) error {
+// 用 sudo 包装命令及其参数，ctx 结束时终止命令
+func RunWithSudo(ctx context.Context, cmd *exec.Cmd) error {
     // If already root, just run the command directly
     if IsRoot() {
-        return cmd.Run()
+        direct := exec.CommandContext(ctx, cmd.Path, cmd.Args[1:]...)
+        direct.Stdout = cmd.Stdout
+        direct.Stderr = cmd.Stderr
+        return direct.Run()
     }
 
     // Check if sudo is available
@@ -49,7 +74,13 @@ func RunWithSudo(cmd *exec.Cmd) error {
     sudoArgs = append(sudoArgs, cmd.Args[1:]...)
 
     // Create new sudo command
-    sudoCmd := exec.Command("sudo", sudoArgs...)
+    sudoCmd := exec.CommandContext(ctx, "sudo", sudoArgs...)
+
+    // sudo relays SIGTERM to the command, SIGKILL would leave it running
+    sudoCmd.Cancel = func() error {
+        return sudoCmd.Process.Signal(syscall.SIGTERM)
+    }
+    sudoCmd.WaitDelay = sudoKillDelay
 
     // Set up stdin for password input
     sudoCmd.Stdin = os.Stdin
@@ -57,6 +88,11 @@ func RunWithSudo(cmd *exec.Cmd) error {
     sudoCmd.Stderr = cmd.Stderr
 
     // Run the sudo command
+    sudoMu.Lock()
+    defer sudoMu.Unlock()
+    if err := ctx.Err(); err != nil {
+        return err
+    }
     if err := sudoCmd.Run(); err != nil {
         return fmt.Errorf("sudo command failed: %w", err)
     }
@@ -0,0 +1,76 @@
+package mount
+
+import (
+    "bufio"
+    "errors"
+    "io"
+    "os"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+// cifsStatsPath 是 cifs 驱动的统计文件，加载 cifs 模块后存在
+const cifsStatsPath = "/proc/fs/cifs/Stats"
+
+// statsShareLine 匹配统计文件中每个共享的标题行，例如 1) \\server\share
+var statsShareLine = regexp.MustCompile(`^\d+\) (\\\\\S+)`)
+
+// Activity 是 cifs 驱动统计的每个共享已发送的 SMB 请求数
+type Activity map[string]uint64
+
+// ReadActivity 读取 cifs 驱动的统计文件
+// 没有加载 cifs 模块时返回空的 Activity
+func ReadActivity() (Activity, error) {
+    f, err := os.Open(cifsStatsPath)
+    if errors.Is(err, os.ErrNotExist) {
+        return Activity{}, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+    return parseActivity(f)
+}
+
+// parseActivity 解析统计文件，取每个共享标题行之后的 SMBs: 计数
+// 同一共享的多个连接的计数相加
+func parseActivity(r io.Reader) (Activity, error) {
+    a := Activity{}
+    var share string
+    scanner := bufio.NewScanner(r)
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        if m := statsShareLine.FindStringSubmatch(line); m != nil {
+            share = shareKey(strings.ReplaceAll(m[1], `\`, "/"))
+            continue
+        }
+        // SMB1 shares continue the line with other counters: SMBs: 12 Oplock Breaks: 0
+        if count, ok := strings.CutPrefix(line, "SMBs:"); ok && share != "" {
+            if fields := strings.Fields(count); len(fields) > 0 {
+                if n, err := strconv.ParseUint(fields[0], 10, 64); err == nil {
+                    a[share] += n
+                }
+            }
+            share = ""
+        }
+    }
+    return a, scanner.Err()
+}
+
+// Requests 返回挂载来源 //server/share 的共享已发送的请求数，统计中没有该共享时 ok 为 false
+func (a Activity) Requests(source string) (n uint64, ok bool) {
+    n, ok = a[shareKey(source)]
+    return n, ok
+}
+
+// shareKey 将 //server[:port]/share[/path] 转换为比较用的 server/share
+func shareKey(source string) string {
+    rest, ok := strings.CutPrefix(source, "//")
+    if !ok {
+        return ""
+    }
+    _, rest, _ = strings.Cut(rest, "/")
+    share, _, _ := strings.Cut(rest, "/")
+    return strings.ToLower(sourceHost(source) + "/" + share)
+}
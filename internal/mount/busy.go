@@ -0,0 +1,102 @@
+package mount
+
+import (
+    "bufio"
+    "errors"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+    "syscall"
+)
+
+// Holder 是正在使用挂载点中文件或目录的进程
+type Holder struct {
+    PID     int
+    Command string
+    Path    string // 被使用的路径
+    Use     string // 使用方式：cwd、root、exe、fd 或 maps
+}
+
+// IsBusy 判断卸载失败是否因为挂载点正被使用
+func IsBusy(err error) bool {
+    return errors.Is(err, syscall.EBUSY)
+}
+
+// FindHolders 扫描 /proc 中每个进程的工作目录、根目录、打开的文件和内存映射，
+// 返回使用 mountPath 下路径的进程，按 PID 排序
+// 没有权限读取的进程会被跳过，因此非 root 用户只能看到自己的进程
+func FindHolders(mountPath string) ([]Holder, error) {
+    procs, err := os.ReadDir("/proc")
+    if err != nil {
+        return nil, err
+    }
+
+    self := os.Getpid()
+    var holders []Holder
+    for _, p := range procs {
+        pid, err := strconv.Atoi(p.Name())
+        if err != nil || pid == self {
+            continue
+        }
+        holders = append(holders, processHolders(pid, mountPath)...)
+    }
+
+    sort.SliceStable(holders, func(i, j int) bool {
+        return holders[i].PID < holders[j].PID
+    })
+    return holders, nil
+}
+
+// processHolders 返回单个进程对 mountPath 下路径的使用，同一路径和方式只记录一次
+func processHolders(pid int, mountPath string) []Holder {
+    dir := filepath.Join("/proc", strconv.Itoa(pid))
+
+    var holders []Holder
+    seen := make(map[string]bool)
+    add := func(path, use string) {
+        path = strings.TrimSuffix(path, " (deleted)")
+        if !isSubPath(path, mountPath) || seen[use+path] {
+            return
+        }
+        seen[use+path] = true
+        holders = append(holders, Holder{PID: pid, Path: path, Use: use})
+    }
+
+    for _, use := range []string{"cwd", "root", "exe"} {
+        if target, err := os.Readlink(filepath.Join(dir, use)); err == nil {
+            add(target, use)
+        }
+    }
+
+    if fds, err := os.ReadDir(filepath.Join(dir, "fd")); err == nil {
+        for _, fd := range fds {
+            if target, err := os.Readlink(filepath.Join(dir, "fd", fd.Name())); err == nil && filepath.IsAbs(target) {
+                add(target, "fd")
+            }
+        }
+    }
+
+    if f, err := os.Open(filepath.Join(dir, "maps")); err == nil {
+        scanner := bufio.NewScanner(f)
+        for scanner.Scan() {
+            // address perms offset dev inode pathname
+            fields := strings.SplitN(scanner.Text(), " ", 6)
+            if len(fields) == 6 {
+                if path := strings.TrimSpace(fields[5]); filepath.IsAbs(path) {
+                    add(path, "maps")
+                }
+            }
+        }
+        f.Close()
+    }
+
+    if len(holders) > 0 {
+        comm, _ := os.ReadFile(filepath.Join(dir, "comm"))
+        for i := range holders {
+            holders[i].Command = strings.TrimSpace(string(comm))
+        }
+    }
+    return holders
+}
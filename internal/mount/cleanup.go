@@ -0,0 +1,116 @@
+package mount
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/hsldymq/smb_mount/internal/state"
+)
+
+// RecordCreated 在状态文件中记录 CreateMountPoint 为条目创建的目录
+// 目录已存在（created 为空）时保留之前的记录，之前创建的目录仍然属于 smb_mount
+func RecordCreated(entry *config.MountEntry, created []string) error {
+    if len(created) == 0 {
+        return nil
+    }
+    return state.Update(func(s *state.State) error {
+        s.Ensure(entry.ActualMountPath, entry.Name).CreatedDirs = created
+        return nil
+    })
+}
+
+// CleanupMountPoint 删除 smb_mount 为挂载点创建的目录，返回被删除的目录
+// 用户自己创建的目录和非空目录不会被删除
+func CleanupMountPoint(mountPath string) ([]string, error) {
+    mounted, err := CheckStatus(mountPath)
+    if err != nil {
+        return nil, err
+    }
+    if mounted {
+        return nil, fmt.Errorf("cannot cleanup: still mounted")
+    }
+
+    var removed []string
+    err = state.Update(func(s *state.State) error {
+        m := s.Get(mountPath)
+        if m == nil || len(m.CreatedDirs) == 0 {
+            return nil
+        }
+        var remaining []string
+        removed, remaining, err = removeCreatedDirs(m.CreatedDirs, false)
+        m.CreatedDirs = remaining
+        return err
+    })
+    return removed, err
+}
+
+// Prune 删除状态文件中记录的、当前未挂载且为空的挂载目录，返回被删除（dryRun 时为将被删除）的目录
+// include 决定是否处理某个挂载路径
+func Prune(include func(mountPath string) bool, dryRun bool) ([]string, error) {
+    var pruned []string
+    update := func(s *state.State) error {
+        for path, m := range s.Mounts {
+            if len(m.CreatedDirs) == 0 || !include(path) {
+                continue
+            }
+            mounted, err := CheckStatus(path)
+            if err != nil || mounted {
+                continue
+            }
+
+            removed, remaining, err := removeCreatedDirs(m.CreatedDirs, dryRun)
+            if err != nil {
+                return err
+            }
+            pruned = append(pruned, removed...)
+            if !dryRun {
+                m.CreatedDirs = remaining
+            }
+        }
+        return nil
+    }
+
+    if dryRun {
+        s, err := state.Load()
+        if err != nil {
+            return nil, err
+        }
+        return pruned, update(s)
+    }
+    return pruned, state.Update(update)
+}
+
+// removeCreatedDirs 由深到浅删除空目录，遇到非空目录时停止
+// 返回被删除的目录和仍需保留记录的目录；已不存在的目录视为已删除
+// dryRun 时不实际删除，只判断哪些目录会被删除
+func removeCreatedDirs(dirs []string, dryRun bool) (removed, remaining []string, err error) {
+    for i, dir := range dirs {
+        entries, err := os.ReadDir(dir)
+        if errors.Is(err, os.ErrNotExist) {
+            continue
+        }
+        if err != nil {
+            return removed, dirs[i:], fmt.Errorf("failed to read %s: %w", dir, err)
+        }
+
+        // In a dry run the deeper directory is still there but would have been removed
+        n := len(entries)
+        if dryRun && n == 1 && len(removed) > 0 && removed[len(removed)-1] == dirs[i-1] && entries[0].Name() == filepath.Base(dirs[i-1]) {
+            n = 0
+        }
+        if n > 0 {
+            return removed, dirs[i:], nil
+        }
+
+        if !dryRun {
+            if err := os.Remove(dir); err != nil {
+                return removed, dirs[i:], fmt.Errorf("failed to remove %s: %w", dir, err)
+            }
+        }
+        removed = append(removed, dir)
+    }
+    return removed, nil, nil
+}
@@ -0,0 +1,61 @@
+package mount
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "os/exec"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/moby/sys/mountinfo"
+)
+
+// ExecMounter 通过调用 mount.cifs 和 umount 命令进行挂载，需要安装 cifs-utils
+type ExecMounter struct{}
+
+// Name 返回后端名称
+func (ExecMounter) Name() string {
+    return BackendExec
+}
+
+// Mount 使用 mount.cifs 挂载条目
+// ctx 被取消或超时时 mount.cifs 进程会被终止
+func (ExecMounter) Mount(ctx context.Context, entry *config.MountEntry) error {
+    // Create credentials file
+    credsFile, err := createCredentialFile(entry)
+    if err != nil {
+        return err
+    }
+    defer os.Remove(credsFile)
+
+    // Build and execute mount command
+    return runCommand(ctx, buildMountCommand(entry, credsFile), "mount")
+}
+
+// Unmount 使用 umount 命令卸载
+func (ExecMounter) Unmount(ctx context.Context, mountPath string, opts UnmountOptions) error {
+    return runCommand(ctx, BuildUmountCommand(mountPath, opts), "umount")
+}
+
+// Remount 使用 mount -o remount 重新挂载
+func (ExecMounter) Remount(ctx context.Context, mountPath string, options []string) error {
+    return runCommand(ctx, BuildRemountCommand(mountPath, options), "remount")
+}
+
+// Mounts 读取系统挂载表
+func (ExecMounter) Mounts() ([]*mountinfo.Info, error) {
+    return systemMounts()
+}
+
+// runCommand 在 ctx 控制下执行命令，ctx 结束时终止进程
+func runCommand(ctx context.Context, cmd *exec.Cmd, op string) error {
+    cmd = exec.CommandContext(ctx, cmd.Path, cmd.Args[1:]...)
+    output, err := cmd.CombinedOutput()
+    if ctxErr := ctx.Err(); ctxErr != nil {
+        return fmt.Errorf("%s aborted: %w", op, ctxErr)
+    }
+    if err != nil {
+        return CommandError(op, err, output)
+    }
+    return nil
+}
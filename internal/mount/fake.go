@@ -0,0 +1,117 @@
+package mount
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "sync"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/moby/sys/mountinfo"
+)
+
+// FakeMounter 是仅在内存中记录挂载状态的后端，用于测试
+// 不会访问网络或修改系统挂载表
+type FakeMounter struct {
+    mu      sync.Mutex
+    mounted map[string]*mountinfo.Info // 挂载路径 -> 模拟的挂载表记录
+
+    // MountErrors 按条目名称指定 Mount 返回的错误
+    MountErrors map[string]error
+    // UnmountErrors 按挂载路径指定 Unmount 返回的错误
+    UnmountErrors map[string]error
+    // RemountErrors 按挂载路径指定 Remount 返回的错误
+    RemountErrors map[string]error
+    // Calls 记录所有调用，格式为 "mount <name>"、"umount <path>" 或 "remount <path> <options>"
+    Calls []string
+}
+
+// NewFakeMounter 创建新的内存后端
+func NewFakeMounter() *FakeMounter {
+    return &FakeMounter{
+        mounted:       make(map[string]*mountinfo.Info),
+        MountErrors:   make(map[string]error),
+        UnmountErrors: make(map[string]error),
+        RemountErrors: make(map[string]error),
+    }
+}
+
+// Name 返回后端名称
+func (f *FakeMounter) Name() string {
+    return "fake"
+}
+
+// Mount 在内存中记录挂载
+func (f *FakeMounter) Mount(ctx context.Context, entry *config.MountEntry) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    if err := ctx.Err(); err != nil {
+        return fmt.Errorf("mount aborted: %w", err)
+    }
+
+    f.Calls = append(f.Calls, "mount "+entry.Name)
+    if err := f.MountErrors[entry.Name]; err != nil {
+        return err
+    }
+    if _, ok := f.mounted[entry.ActualMountPath]; ok {
+        return fmt.Errorf("mount failed: %s is busy", entry.ActualMountPath)
+    }
+    f.mounted[entry.ActualMountPath] = &mountinfo.Info{
+        Mountpoint: entry.ActualMountPath,
+        FSType:     "cifs",
+        Source:     fmt.Sprintf("//%s/%s", entry.GetSMBAddr(), entry.ShareName),
+    }
+    return nil
+}
+
+// Unmount 在内存中移除挂载记录
+func (f *FakeMounter) Unmount(ctx context.Context, mountPath string, opts UnmountOptions) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    if err := ctx.Err(); err != nil {
+        return fmt.Errorf("umount aborted: %w", err)
+    }
+
+    f.Calls = append(f.Calls, "umount "+mountPath)
+    if err := f.UnmountErrors[mountPath]; err != nil {
+        return err
+    }
+    if _, ok := f.mounted[mountPath]; !ok {
+        return fmt.Errorf("umount failed: %s not mounted", mountPath)
+    }
+    delete(f.mounted, mountPath)
+    return nil
+}
+
+// Remount 在内存中记录重新挂载
+func (f *FakeMounter) Remount(ctx context.Context, mountPath string, options []string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    if err := ctx.Err(); err != nil {
+        return fmt.Errorf("remount aborted: %w", err)
+    }
+
+    f.Calls = append(f.Calls, "remount "+mountPath+" "+strings.Join(options, ","))
+    if err := f.RemountErrors[mountPath]; err != nil {
+        return err
+    }
+    if _, ok := f.mounted[mountPath]; !ok {
+        return fmt.Errorf("remount failed: %s not mounted", mountPath)
+    }
+    return nil
+}
+
+// Mounts 返回内存中记录的挂载，文件系统类型为 cifs
+func (f *FakeMounter) Mounts() ([]*mountinfo.Info, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    mounts := make([]*mountinfo.Info, 0, len(f.mounted))
+    for _, m := range f.mounted {
+        mounts = append(mounts, m)
+    }
+    return mounts, nil
+}
@@ -0,0 +1,53 @@
+package mount
+
+import (
+    "errors"
+    "fmt"
+    "sync"
+    "time"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "golang.org/x/sys/unix"
+)
+
+// ErrStale 表示挂载点没有响应，通常是服务器已不可达
+var ErrStale = errors.New("mount is not responding")
+
+// CheckHealth 在 timeout 内对挂载点执行 statfs，超时或失败时返回包装 ErrStale 的错误
+// 阻塞在失去响应的 cifs 挂载上的 statfs 无法中断，执行它的 goroutine 会保留到进程退出
+func CheckHealth(mountPath string, timeout time.Duration) error {
+    done := make(chan error, 1)
+    go func() {
+        var st unix.Statfs_t
+        done <- unix.Statfs(mountPath, &st)
+    }()
+
+    select {
+    case err := <-done:
+        if err != nil {
+            return fmt.Errorf("%w: statfs failed: %w", ErrStale, err)
+        }
+        return nil
+    case <-time.After(timeout):
+        return fmt.Errorf("%w: no answer within %s", ErrStale, timeout)
+    }
+}
+
+// CheckAllHealth 同时检查所有已挂载条目是否响应，没有响应的条目标记为 IsStale
+func CheckAllHealth(cfg *config.Config, timeout time.Duration) {
+    var wg sync.WaitGroup
+    for i := range cfg.Mounts {
+        entry := &cfg.Mounts[i]
+        entry.IsStale = false
+        if !entry.IsMounted {
+            continue
+        }
+
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            entry.IsStale = CheckHealth(entry.ActualMountPath, timeout) != nil
+        }()
+    }
+    wg.Wait()
+}
@@ -1,14 +1,25 @@
 package mount
 
 import (
+    "context"
     "fmt"
     "github.com/hsldymq/smb_mount/internal/config"
     "os"
     "os/exec"
+    "path/filepath"
+    "strings"
 )
 
 // Mount 对单个挂载条目执行挂载操作
-func Mount(entry *config.MountEntry) error {
+// 挂载前检查挂载目标是否安全，opts.ForceTarget 时跳过检查
+// 挂载失败、超时或被取消时，会删除本次尝试中创建的目录；挂载成功时在状态文件中记录这些目录
+func Mount(ctx context.Context, entry *config.MountEntry, opts MountOptions) error {
+    if !opts.ForceTarget {
+        if err := CheckTarget(entry); err != nil {
+            return err
+        }
+    }
+
     // Check if already mounted
     mounted, err := CheckEntryStatus(entry)
     if err != nil {
@@ -19,27 +30,61 @@ func Mount(entry *config.MountEntry) error {
     }
 
     // Create mount directory if it doesn't exist
-    if err := os.MkdirAll(entry.ActualMountPath, 0755); err != nil {
-        return &MountError{Op: "mount", Path: entry.ActualMountPath, Err: fmt.Errorf("failed to create mount directory: %w", err)}
+    created, err := CreateMountPoint(entry)
+    if err != nil {
+        return &MountError{Op: "mount", Path: entry.ActualMountPath, Err: err}
     }
 
-    // Create credentials file
-    credsFile, err := createCredentialFile(entry)
-    if err != nil {
+    // Mount using the configured backend
+    if err := Default().Mount(ctx, entry); err != nil {
+        RemoveCreated(created)
         return &MountError{Op: "mount", Path: entry.ActualMountPath, Err: err}
     }
-    defer os.Remove(credsFile)
 
-    // Build mount command
-    cmd := buildMountCommand(entry, credsFile)
+    if err := RecordCreated(entry, created); err != nil {
+        fmt.Fprintf(os.Stderr, "Warning: failed to record created directories for %s: %v\n", entry.Name, err)
+    }
+    return nil
+}
 
-    // Execute mount command
-    output, err := cmd.CombinedOutput()
-    if err != nil {
-        return &MountError{Op: "mount", Path: entry.ActualMountPath, Err: fmt.Errorf("mount failed: %w\nOutput: %s", err, string(output))}
+// CreateMountPoint 创建条目的挂载目录及其缺失的父目录，设置了 entry.Owner 时新建的目录交给属主
+// 返回本次新创建的目录（由深到浅），供失败时通过 RemoveCreated 回滚
+func CreateMountPoint(entry *config.MountEntry) ([]string, error) {
+    path := entry.ActualMountPath
+    var created []string
+    for dir := path; ; dir = filepath.Dir(dir) {
+        if _, err := os.Stat(dir); err == nil {
+            break
+        } else if !os.IsNotExist(err) {
+            return nil, fmt.Errorf("failed to access mount directory: %w", err)
+        }
+        created = append(created, dir)
+        if parent := filepath.Dir(dir); parent == dir {
+            break
+        }
     }
 
-    return nil
+    if err := os.MkdirAll(path, 0755); err != nil {
+        return nil, fmt.Errorf("failed to create mount directory: %w", err)
+    }
+    if entry.Owner != nil {
+        for _, dir := range created {
+            if err := os.Lchown(dir, entry.Owner.UID, entry.Owner.GID); err != nil {
+                RemoveCreated(created)
+                return nil, fmt.Errorf("failed to change owner of mount directory: %w", err)
+            }
+        }
+    }
+    return created, nil
+}
+
+// RemoveCreated 删除 CreateMountPoint 创建的目录，只删除空目录
+func RemoveCreated(created []string) {
+    for _, dir := range created {
+        if err := os.Remove(dir); err != nil {
+            return
+        }
+    }
 }
 
 // CreateCredentialFile 为外部使用创建临时凭据文件
@@ -85,16 +130,28 @@ func BuildMountCommand(entry *config.MountEntry, credsFile string) *exec.Cmd {
 // buildMountCommand 构建 mount.cifs 命令
 func buildMountCommand(entry *config.MountEntry, credsFile string) *exec.Cmd {
     // Build SMB address
-    smbAddr := fmt.Sprintf("//%s:%d/%s", entry.SMBAddr, entry.GetSMBPort(), entry.ShareName)
+    smbAddr := fmt.Sprintf("//%s:%d/%s", entry.GetSMBAddr(), entry.GetSMBPort(), entry.ShareName)
 
     // Build mount options
     // Using common mount options for better compatibility
+    uid, gid := entry.GetOwner()
     options := fmt.Sprintf("credentials=%s,file_mode=0755,dir_mode=0755,uid=%d,gid=%d",
         credsFile,
-        os.Getuid(),
-        os.Getgid(),
+        uid,
+        gid,
     )
 
+    // Through an SSH tunnel, connect to the local end but keep the server name for SMB
+    if entry.TunnelPort != 0 {
+        smbAddr = fmt.Sprintf("//%s/%s", entry.SMBAddr, entry.ShareName)
+        options += fmt.Sprintf(",ip=127.0.0.1,port=%d", entry.TunnelPort)
+    }
+
+    // Extra options from the config come last so they can override the defaults
+    if len(entry.Options) > 0 {
+        options += "," + strings.Join(entry.Options, ",")
+    }
+
     // Build command: mount.cifs //server/share /mount/path -o options
     args := []string{
         smbAddr,
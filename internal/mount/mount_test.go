@@ -0,0 +1,31 @@
+package mount
+
+import (
+    "fmt"
+    "os"
+    "strings"
+    "testing"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+)
+
+func TestMountOwner(t *testing.T) {
+    entry := &config.MountEntry{SMBAddr: "nas", ShareName: "media", Username: "u", Password: "p", ActualMountPath: "/mnt/media"}
+    current := fmt.Sprintf("uid=%d,gid=%d", os.Getuid(), os.Getgid())
+    owner := "uid=1000,gid=100"
+
+    if data := buildMountData(entry, "10.0.0.1", 445); !strings.Contains(data, current) {
+        t.Errorf("buildMountData() = %q, want %s by default", data, current)
+    }
+    if args := strings.Join(buildMountCommand(entry, "/tmp/creds").Args, " "); !strings.Contains(args, current) {
+        t.Errorf("buildMountCommand() = %q, want %s by default", args, current)
+    }
+
+    entry.Owner = &config.Owner{UID: 1000, GID: 100}
+    if data := buildMountData(entry, "10.0.0.1", 445); !strings.Contains(data, owner) {
+        t.Errorf("buildMountData() = %q, want %s", data, owner)
+    }
+    if args := strings.Join(buildMountCommand(entry, "/tmp/creds").Args, " "); !strings.Contains(args, owner) {
+        t.Errorf("buildMountCommand() = %q, want %s", args, owner)
+    }
+}
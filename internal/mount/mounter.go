@@ -0,0 +1,72 @@
+package mount
+
+import (
+    "context"
+    "fmt"
+    "sync"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/moby/sys/mountinfo"
+)
+
+// Mounter 是执行实际挂载和卸载操作的后端
+type Mounter interface {
+    // Name 返回后端名称
+    Name() string
+
+    // Mount 将条目挂载到 entry.ActualMountPath，挂载目录需已存在
+    // ctx 被取消或超时时应尽快返回
+    Mount(ctx context.Context, entry *config.MountEntry) error
+
+    // Unmount 卸载指定路径上的文件系统
+    Unmount(ctx context.Context, mountPath string, opts UnmountOptions) error
+
+    // Remount 以新的选项重新挂载已挂载的路径（MS_REMOUNT），不中断已打开的文件
+    Remount(ctx context.Context, mountPath string, options []string) error
+
+    // Mounts 返回当前的挂载表，挂载状态的检查都基于一次读取的挂载表
+    Mounts() ([]*mountinfo.Info, error)
+}
+
+// UnmountOptions 卸载选项
+type UnmountOptions struct {
+    Lazy  bool // 立即从目录树中分离，文件系统不再被使用后才真正卸载（MNT_DETACH）
+    Force bool // 服务器无响应时强制卸载（MNT_FORCE）
+}
+
+// 可用的后端名称
+const (
+    BackendExec    = "exec"
+    BackendSyscall = "syscall"
+)
+
+var (
+    mu      sync.RWMutex
+    current Mounter = ExecMounter{}
+)
+
+// Default 返回当前使用的挂载后端
+func Default() Mounter {
+    mu.RLock()
+    defer mu.RUnlock()
+    return current
+}
+
+// SetDefault 设置挂载后端，供命令行选择后端和测试替换使用
+func SetDefault(m Mounter) {
+    mu.Lock()
+    defer mu.Unlock()
+    current = m
+}
+
+// NewMounter 根据名称创建挂载后端
+func NewMounter(name string) (Mounter, error) {
+    switch name {
+    case "", BackendExec:
+        return ExecMounter{}, nil
+    case BackendSyscall:
+        return SyscallMounter{}, nil
+    default:
+        return nil, fmt.Errorf("unknown mount backend '%s'", name)
+    }
+}
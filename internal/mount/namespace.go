@@ -0,0 +1,28 @@
+package mount
+
+import (
+    "fmt"
+    "runtime"
+    "sync/atomic"
+
+    "golang.org/x/sys/unix"
+)
+
+// privateNamespace 记录进程是否已通过 EnterPrivateNamespace 进入私有挂载命名空间
+var privateNamespace atomic.Bool
+
+// EnterPrivateNamespace 让调用的 goroutine 所在线程进入新的挂载命名空间，并将所有挂载的传播类型设为私有
+// 之后在其中的挂载和卸载不会传播出去，其他会话看不到；需要 CAP_SYS_ADMIN
+// 命名空间只属于当前线程，goroutine 会一直固定在该线程上，挂载、读取挂载表和启动子进程都需要在此 goroutine 中进行
+func EnterPrivateNamespace() error {
+    runtime.LockOSThread()
+
+    if err := unix.Unshare(unix.CLONE_NEWNS); err != nil {
+        return fmt.Errorf("failed to create mount namespace: %w", err)
+    }
+    if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+        return fmt.Errorf("failed to make mounts private: %w", err)
+    }
+    privateNamespace.Store(true)
+    return nil
+}
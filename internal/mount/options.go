@@ -0,0 +1,80 @@
+package mount
+
+import (
+    "strings"
+
+    "github.com/moby/sys/mountinfo"
+    "golang.org/x/sys/unix"
+)
+
+// ParseOptions 拆分以逗号分隔的挂载选项，忽略空项
+func ParseOptions(s string) []string {
+    var opts []string
+    for _, opt := range strings.Split(s, ",") {
+        if opt = strings.TrimSpace(opt); opt != "" {
+            opts = append(opts, opt)
+        }
+    }
+    return opts
+}
+
+// MergeOptions 合并挂载选项，override 中的选项替换 base 中同名的选项，ro 和 rw 互相替换
+func MergeOptions(base, override []string) []string {
+    merged := append([]string(nil), base...)
+    for _, opt := range override {
+        key := optionKey(opt)
+        replaced := false
+        for i, existing := range merged {
+            if optionKey(existing) == key {
+                merged[i] = opt
+                replaced = true
+            }
+        }
+        if !replaced {
+            merged = append(merged, opt)
+        }
+    }
+    return merged
+}
+
+// optionKey 返回选项名，ro 和 rw 视为同一个选项
+func optionKey(opt string) string {
+    key, _, _ := strings.Cut(opt, "=")
+    if key == "ro" {
+        return "rw"
+    }
+    return key
+}
+
+// splitFlags 将选项拆分为 mount(2) 的标志和传给 cifs 驱动的数据
+func splitFlags(opts []string) (uintptr, []string) {
+    var flags uintptr
+    var data []string
+    for _, opt := range opts {
+        switch opt {
+        case "ro":
+            flags |= unix.MS_RDONLY
+        case "rw":
+        default:
+            data = append(data, opt)
+        }
+    }
+    return flags, data
+}
+
+// MissingOptions 返回在挂载表中找不到的选项
+// ro/rw 比较挂载点和超级块的选项，其他选项比较 cifs 驱动报告的超级块选项
+func MissingOptions(info *mountinfo.Info, opts []string) []string {
+    present := make(map[string]bool)
+    for _, opt := range ParseOptions(info.Options + "," + info.VFSOptions) {
+        present[opt] = true
+    }
+
+    var missing []string
+    for _, opt := range opts {
+        if !present[opt] {
+            missing = append(missing, opt)
+        }
+    }
+    return missing
+}
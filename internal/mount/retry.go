@@ -0,0 +1,103 @@
+package mount
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "math/rand/v2"
+    "regexp"
+    "strconv"
+    "syscall"
+    "time"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+)
+
+// transientErrnos 是可以通过重试解决的网络错误
+var transientErrnos = []syscall.Errno{
+    syscall.EHOSTUNREACH,
+    syscall.ETIMEDOUT,
+    syscall.ECONNREFUSED,
+}
+
+// mountErrorPattern 匹配 mount.cifs 输出中的错误码，例如 "mount error(113): No route to host"
+var mountErrorPattern = regexp.MustCompile(`mount error\((\d+)\)`)
+
+// IsTransient 判断错误是否为临时网络错误（主机不可达、超时、连接被拒绝）
+func IsTransient(err error) bool {
+    for _, errno := range transientErrnos {
+        if errors.Is(err, errno) {
+            return true
+        }
+    }
+    return false
+}
+
+// busyPattern 匹配 umount 报告挂载点正被使用的输出
+var busyPattern = regexp.MustCompile(`(target|device) is busy`)
+
+// CommandError 将外部命令的失败和输出组合为错误
+// 输出中包含 mount.cifs 的错误码或 umount 的 "target is busy" 时，返回的错误同时包装对应的 syscall.Errno
+func CommandError(op string, err error, output []byte) error {
+    if errno := outputErrno(output); errno != 0 {
+        return fmt.Errorf("%s failed: %w: %w\nOutput: %s", op, err, errno, string(output))
+    }
+    return fmt.Errorf("%s failed: %w\nOutput: %s", op, err, string(output))
+}
+
+// outputErrno 从命令输出中识别错误码，无法识别时返回 0
+func outputErrno(output []byte) syscall.Errno {
+    if m := mountErrorPattern.FindSubmatch(output); m != nil {
+        if n, err := strconv.Atoi(string(m[1])); err == nil {
+            return syscall.Errno(n)
+        }
+    }
+    if busyPattern.Match(output) {
+        return syscall.EBUSY
+    }
+    return 0
+}
+
+// RetryDelay 返回第 attempt 次尝试失败后的等待时间（attempt 从 1 开始）
+// 等待时间从 InitialDelay 开始每次翻倍，不超过 MaxDelay，并按 Jitter 随机浮动
+func RetryDelay(policy config.RetryPolicy, attempt int) time.Duration {
+    delay := policy.InitialDelay
+    for i := 1; i < attempt && delay < policy.MaxDelay; i++ {
+        delay *= 2
+    }
+    delay = min(delay, policy.MaxDelay)
+
+    if policy.Jitter > 0 {
+        factor := 1 + policy.Jitter*(2*rand.Float64()-1)
+        delay = time.Duration(float64(delay) * factor)
+    }
+    return delay
+}
+
+// Retry 按重试策略执行 op，只有临时网络错误才会重试
+// 每次重试前调用 onRetry 报告失败的尝试；ctx 结束时停止等待并返回最后一次的错误
+func Retry(ctx context.Context, policy config.RetryPolicy, op func(attempt int) error, onRetry func(attempt int, err error, delay time.Duration)) error {
+    attempts := max(policy.Attempts, 1)
+
+    var err error
+    for attempt := 1; attempt <= attempts; attempt++ {
+        if err = op(attempt); err == nil {
+            return nil
+        }
+        if attempt == attempts || !IsTransient(err) || ctx.Err() != nil {
+            break
+        }
+
+        delay := RetryDelay(policy, attempt)
+        if onRetry != nil {
+            onRetry(attempt, err, delay)
+        }
+
+        select {
+        case <-time.After(delay):
+        case <-ctx.Done():
+            return err
+        }
+    }
+    return err
+}
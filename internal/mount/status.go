@@ -1,9 +1,13 @@
 package mount
 
 import (
+    "errors"
     "fmt"
+    "io"
+    "net"
     "os"
     "path/filepath"
+    "strings"
 
     "github.com/hsldymq/smb_mount/internal/config"
     "github.com/moby/sys/mountinfo"
@@ -11,57 +15,134 @@ import (
 
 // CheckStatus 检查指定路径是否已挂载
 func CheckStatus(mountPath string) (bool, error) {
-    // Normalize the path
-    absPath, err := filepath.Abs(mountPath)
+    t, err := ReadTable()
     if err != nil {
-        return false, fmt.Errorf("failed to resolve absolute path: %w", err)
+        return false, err
     }
+    return t.Lookup(mountPath) != nil, nil
+}
 
-    // Check if mount point exists
-    if _, err := os.Stat(absPath); os.IsNotExist(err) {
-        return false, nil
+// systemMounts 读取当前线程所在挂载命名空间的挂载表
+// 读取 /proc/thread-self 而不是 /proc/self，进入私有命名空间的线程读到的是自己的挂载表
+func systemMounts() ([]*mountinfo.Info, error) {
+    f, err := os.Open("/proc/thread-self/mountinfo")
+    if err != nil {
+        return nil, err
     }
+    defer f.Close()
 
-    // Use moby/sys/mountinfo to check mount status
-    mounted, err := mountinfo.Mounted(absPath)
-    if err != nil {
-        return false, fmt.Errorf("failed to check mount status: %w", err)
+    return readMounts(f)
+}
+
+// readMounts 解析 mountinfo 格式的挂载表
+func readMounts(r io.Reader) ([]*mountinfo.Info, error) {
+    return mountinfo.GetMountsFromReader(r, nil)
+}
+
+// MountState 挂载路径的状态
+type MountState int
+
+const (
+    StateUnmounted MountState = iota // 没有挂载
+    StateMounted                     // 挂载的是条目配置的共享
+    StateForeign                     // 挂载的是其他文件系统
+)
+
+func (s MountState) String() string {
+    switch s {
+    case StateMounted:
+        return "mounted"
+    case StateForeign:
+        return "foreign"
+    default:
+        return "unmounted"
     }
+}
+
+// Status 挂载路径的状态及挂载表中记录的文件系统
+type Status struct {
+    State  MountState
+    FSType string
+    Source string
+}
 
-    return mounted, nil
+// Describe 返回挂载的来源和文件系统类型，例如 "//nas/share (cifs)"
+func (s Status) Describe() string {
+    return fmt.Sprintf("%s (%s)", s.Source, s.FSType)
 }
 
-// CheckEntryStatus 检查单个挂载条目的挂载状态
+// errNotMounted 表示挂载表中没有该路径
+var errNotMounted = errors.New("not mounted")
+
+// EntryStatus 检查条目的挂载路径上挂载的是否为条目配置的共享
+// 比较挂载表中的文件系统类型（cifs/smb3）和来源（//host/share），路径上的其他挂载报告为 StateForeign
+func EntryStatus(entry *config.MountEntry) (Status, error) {
+    t, err := ReadTable()
+    if err != nil {
+        return Status{State: StateUnmounted}, err
+    }
+    return t.Status(entry), nil
+}
+
+// CheckEntryStatus 检查单个挂载条目的共享是否已挂载，路径上挂载了其他文件系统时返回 false
 func CheckEntryStatus(entry *config.MountEntry) (bool, error) {
-    return CheckStatus(entry.ActualMountPath)
+    status, err := EntryStatus(entry)
+    return status.State == StateMounted, err
 }
 
-// RefreshAllStatus 更新配置中所有条目的挂载状态
-func RefreshAllStatus(cfg *config.Config) error {
+// RefreshAllStatus 读取一次挂载表，更新配置中所有条目的挂载状态
+// 已挂载的条目从挂载表中读取实际使用的服务器地址，挂载了其他文件系统的条目记录其来源
+// 返回的状态与 cfg.Mounts 一一对应；不会访问挂载路径本身，失去响应的挂载不会使其阻塞
+func RefreshAllStatus(cfg *config.Config) ([]Status, error) {
+    t, err := ReadTable()
+    if err != nil {
+        return nil, err
+    }
+
+    statuses := make([]Status, len(cfg.Mounts))
     for i := range cfg.Mounts {
-        mounted, err := CheckEntryStatus(&cfg.Mounts[i])
-        if err != nil {
-            // Log error but continue checking other entries
-            fmt.Fprintf(os.Stderr, "Warning: failed to check status for %s: %v\n", cfg.Mounts[i].Name, err)
+        entry := &cfg.Mounts[i]
+        status := t.Status(entry)
+        statuses[i] = status
+
+        entry.IsMounted = status.State == StateMounted
+        entry.ForeignMount = ""
+        switch status.State {
+        case StateMounted:
+            entry.ActiveAddr = sourceHost(status.Source)
+        case StateForeign:
+            entry.ForeignMount = status.Describe()
         }
-        cfg.Mounts[i].IsMounted = mounted
     }
-    return nil
+    return statuses, nil
 }
 
-// GetMountInfo 获取路径的详细挂载信息
+// GetMountInfo 获取挂载在该路径上的文件系统信息
+// 只匹配挂载点本身，不包括其下的嵌套挂载；多个文件系统叠加挂载时返回最上层的一个
 func GetMountInfo(mountPath string) (*mountinfo.Info, error) {
-    // Get all mount points
-    mounts, err := mountinfo.GetMounts(mountinfo.PrefixFilter(mountPath))
+    t, err := ReadTable()
     if err != nil {
-        return nil, fmt.Errorf("failed to get mount info: %w", err)
+        return nil, err
     }
 
-    if len(mounts) == 0 {
-        return nil, fmt.Errorf("not mounted")
+    info := t.Lookup(mountPath)
+    if info == nil {
+        return nil, errNotMounted
     }
+    return info, nil
+}
 
-    return mounts[0], nil
+// sourceHost 从 //host[:port]/share 形式的挂载源中提取服务器地址
+func sourceHost(source string) string {
+    rest, ok := strings.CutPrefix(source, "//")
+    if !ok {
+        return ""
+    }
+    host, _, _ := strings.Cut(rest, "/")
+    if h, _, err := net.SplitHostPort(host); err == nil {
+        return h
+    }
+    return strings.Trim(host, "[]")
 }
 
 // isSubPath 检查 path 是否是 base 的子路径
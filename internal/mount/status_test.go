@@ -0,0 +1,71 @@
+package mount
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/moby/sys/mountinfo"
+)
+
+// mountinfoMounter 是从 mountinfo 文本读取挂载表的内存后端，与 systemMounts 使用相同的解析
+type mountinfoMounter struct {
+    *FakeMounter
+    text string
+}
+
+// Mounts 解析 mountinfo 文本
+func (m mountinfoMounter) Mounts() ([]*mountinfo.Info, error) {
+    return readMounts(strings.NewReader(m.text))
+}
+
+// BenchmarkRefreshAllStatus 在有数千个挂载的主机上（例如运行大量容器）刷新 50 个条目的状态，其中一半已挂载
+// 每次刷新都解析一遍 mountinfo 文本，衡量读取一次挂载表的完整开销
+func BenchmarkRefreshAllStatus(b *testing.B) {
+    const entries, otherMounts = 50, 5000
+
+    var text strings.Builder
+    fmt.Fprintf(&text, "1 0 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw\n")
+    for i := range otherMounts {
+        fmt.Fprintf(&text, "%d 1 0:%d / /var/lib/docker/overlay2/%064x/merged rw,relatime shared:%d - overlay overlay rw,lowerdir=/var/lib/docker/overlay2/l/%d,upperdir=/var/lib/docker/overlay2/%064x/diff\n",
+            i+100, i+100, i, i+2, i, i)
+    }
+
+    cfg := &config.Config{BaseDir: "/mnt/smb"}
+    for i := range entries {
+        entry := config.MountEntry{
+            Name:            fmt.Sprintf("share-%d", i),
+            SMBAddr:         "nas.example.com",
+            ShareName:       fmt.Sprintf("share-%d", i),
+            ActualMountPath: fmt.Sprintf("/mnt/smb/share-%d", i),
+        }
+        if i%2 == 0 {
+            fmt.Fprintf(&text, "%d 1 0:%d / %s rw,relatime - cifs //nas.example.com/%s rw,vers=3.1.1,cache=strict\n",
+                otherMounts+i+100, otherMounts+i+100, entry.ActualMountPath, entry.ShareName)
+        }
+        cfg.Mounts = append(cfg.Mounts, entry)
+    }
+
+    previous := Default()
+    SetDefault(mountinfoMounter{FakeMounter: NewFakeMounter(), text: text.String()})
+    b.Cleanup(func() { SetDefault(previous) })
+
+    b.ResetTimer()
+    for range b.N {
+        if _, err := RefreshAllStatus(cfg); err != nil {
+            b.Fatal(err)
+        }
+    }
+    b.StopTimer()
+
+    mounted := 0
+    for _, entry := range cfg.Mounts {
+        if entry.IsMounted {
+            mounted++
+        }
+    }
+    if mounted != entries/2 {
+        b.Fatalf("%d entries mounted, want %d", mounted, entries/2)
+    }
+}
@@ -0,0 +1,149 @@
+package mount
+
+import (
+    "context"
+    "fmt"
+    "net"
+    "strings"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/moby/sys/mountinfo"
+    "golang.org/x/sys/unix"
+)
+
+// SyscallMounter 直接调用 mount(2) 和 umount(2)，不依赖 cifs-utils
+// 内核不会解析主机名，因此由本后端自行解析服务器地址
+type SyscallMounter struct{}
+
+// Name 返回后端名称
+func (SyscallMounter) Name() string {
+    return BackendSyscall
+}
+
+// Mount 使用 mount(2) 挂载条目
+// 系统调用本身无法中断，ctx 结束时立即返回，稍后完成的挂载会被自动卸载
+func (SyscallMounter) Mount(ctx context.Context, entry *config.MountEntry) error {
+    ip, port := "127.0.0.1", entry.TunnelPort
+    if entry.TunnelPort == 0 {
+        var err error
+        if ip, err = resolveHost(ctx, entry.GetSMBAddr()); err != nil {
+            return err
+        }
+        port = entry.GetSMBPort()
+    }
+
+    source := fmt.Sprintf("//%s/%s", entry.GetSMBAddr(), entry.ShareName)
+    flags, _ := splitFlags(entry.Options)
+    data := buildMountData(entry, ip, port)
+
+    return runSyscall(ctx, "mount", func() error {
+        err := unix.Mount(source, entry.ActualMountPath, "cifs", flags, data)
+        if err == nil && ctx.Err() != nil {
+            // Caller has given up, don't leave the late mount behind
+            _ = unix.Unmount(entry.ActualMountPath, unix.MNT_DETACH)
+            return ctx.Err()
+        }
+        return err
+    })
+}
+
+// Unmount 使用 umount(2) 卸载
+func (SyscallMounter) Unmount(ctx context.Context, mountPath string, opts UnmountOptions) error {
+    var flags int
+    if opts.Lazy {
+        flags |= unix.MNT_DETACH
+    }
+    if opts.Force {
+        flags |= unix.MNT_FORCE
+    }
+
+    return runSyscall(ctx, "umount", func() error {
+        return unix.Unmount(mountPath, flags)
+    })
+}
+
+// Remount 使用 mount(2) 的 MS_REMOUNT 重新挂载
+func (SyscallMounter) Remount(ctx context.Context, mountPath string, options []string) error {
+    flags, data := splitFlags(options)
+
+    return runSyscall(ctx, "remount", func() error {
+        return unix.Mount("", mountPath, "cifs", unix.MS_REMOUNT|flags, strings.Join(data, ","))
+    })
+}
+
+// runSyscall 在新的 goroutine 中执行挂载相关的系统调用，ctx 结束时立即返回，不等待系统调用完成
+// 进入私有挂载命名空间后，命名空间只属于调用者的线程（参见 EnterPrivateNamespace），
+// 系统调用必须在调用者的 goroutine 中执行，此时无法被 ctx 中断
+func runSyscall(ctx context.Context, op string, call func() error) error {
+    if privateNamespace.Load() {
+        if err := call(); err != nil {
+            return fmt.Errorf("%s failed: %w", op, err)
+        }
+        return nil
+    }
+
+    done := make(chan error, 1)
+    go func() {
+        done <- call()
+    }()
+
+    select {
+    case err := <-done:
+        if err != nil {
+            return fmt.Errorf("%s failed: %w", op, err)
+        }
+        return nil
+    case <-ctx.Done():
+        return fmt.Errorf("%s aborted: %w", op, ctx.Err())
+    }
+}
+
+// Mounts 读取系统挂载表
+func (SyscallMounter) Mounts() ([]*mountinfo.Info, error) {
+    return systemMounts()
+}
+
+// buildMountData 构建传给 cifs 内核模块的选项字符串
+// ip 和 port 是实际连接的地址，经 SSH 隧道挂载时为隧道的本地端
+func buildMountData(entry *config.MountEntry, ip string, port int) string {
+    uid, gid := entry.GetOwner()
+    options := []string{
+        "ip=" + ip,
+        fmt.Sprintf("port=%d", port),
+        "username=" + escapeMountOption(entry.Username),
+        "password=" + escapeMountOption(entry.Password),
+        "file_mode=0755",
+        "dir_mode=0755",
+        fmt.Sprintf("uid=%d", uid),
+        fmt.Sprintf("gid=%d", gid),
+    }
+    _, extra := splitFlags(entry.Options)
+    return strings.Join(append(options, extra...), ",")
+}
+
+// escapeMountOption 转义选项值中的逗号，cifs 将 ",," 解析为字面量逗号
+func escapeMountOption(value string) string {
+    return strings.ReplaceAll(value, ",", ",,")
+}
+
+// resolveHost 将服务器地址解析为 IP，优先使用 IPv4
+func resolveHost(ctx context.Context, host string) (string, error) {
+    if ip := net.ParseIP(host); ip != nil {
+        return ip.String(), nil
+    }
+
+    addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+    if err != nil {
+        return "", fmt.Errorf("failed to resolve %s: %w", host, err)
+    }
+    if len(addrs) == 0 {
+        return "", fmt.Errorf("failed to resolve %s: no addresses found", host)
+    }
+
+    for _, addr := range addrs {
+        if addr.IP.To4() != nil {
+            return addr.IP.String(), nil
+        }
+    }
+    return addrs[0].IP.String(), nil
+}
@@ -0,0 +1,97 @@
+package mount
+
+import (
+    "fmt"
+    "path/filepath"
+    "slices"
+    "strings"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/moby/sys/mountinfo"
+)
+
+// Table 是按挂载点索引的挂载表，读取一次后可以查询任意多个路径
+type Table struct {
+    byPath map[string][]*mountinfo.Info // 挂载点 -> 该路径上的挂载，按挂载顺序排列，最后一个在最上层
+}
+
+// NewTable 为挂载列表建立按挂载点的索引
+func NewTable(mounts []*mountinfo.Info) *Table {
+    t := &Table{byPath: make(map[string][]*mountinfo.Info, len(mounts))}
+    for _, m := range mounts {
+        t.byPath[m.Mountpoint] = append(t.byPath[m.Mountpoint], m)
+    }
+    return t
+}
+
+// ReadTable 从当前后端读取一次挂载表
+func ReadTable() (*Table, error) {
+    mounts, err := Default().Mounts()
+    if err != nil {
+        return nil, fmt.Errorf("failed to read mount table: %w", err)
+    }
+    return NewTable(mounts), nil
+}
+
+// Lookup 返回挂载在该路径上最上层的文件系统，没有挂载时返回 nil
+// 只匹配挂载点本身，不包括其下的嵌套挂载
+func (t *Table) Lookup(mountPath string) *mountinfo.Info {
+    stack := t.byPath[t.resolve(mountPath)]
+    if len(stack) == 0 {
+        return nil
+    }
+    return stack[len(stack)-1]
+}
+
+// Status 返回条目的挂载路径上的挂载状态
+func (t *Table) Status(entry *config.MountEntry) Status {
+    info := t.Lookup(entry.ActualMountPath)
+    if info == nil {
+        return Status{State: StateUnmounted}
+    }
+
+    status := Status{State: StateForeign, FSType: info.FSType, Source: info.Source}
+    if isSameShare(info, entry) {
+        status.State = StateMounted
+    }
+    return status
+}
+
+// remoteFSTypes 是服务器失去响应时访问会阻塞的文件系统类型
+var remoteFSTypes = []string{"cifs", "smb3", "nfs", "nfs4"}
+
+// resolve 将路径转换为挂载表中使用的形式
+// 清理后的绝对路径本身是挂载点时直接使用，不访问文件系统；否则逐级解析父目录中的符号链接，
+// 遇到网络文件系统的挂载点时停止，该挂载点及其下的路径按原样使用，失去响应的 cifs 挂载上的 lstat 会一直阻塞
+func (t *Table) resolve(mountPath string) string {
+    absPath, err := filepath.Abs(mountPath)
+    if err != nil {
+        return mountPath
+    }
+    if _, ok := t.byPath[absPath]; ok {
+        return absPath
+    }
+
+    parent, name := filepath.Split(absPath)
+    resolved := "/"
+    parts := strings.Split(strings.Trim(parent, "/"), "/")
+    for i, part := range parts {
+        if part == "" {
+            continue
+        }
+        next := filepath.Join(resolved, part)
+        if t.isRemote(next) {
+            return filepath.Join(next, filepath.Join(parts[i+1:]...), name)
+        }
+        if resolved, err = filepath.EvalSymlinks(next); err != nil {
+            return absPath
+        }
+    }
+    return filepath.Join(resolved, name)
+}
+
+// isRemote 返回路径上最上层的挂载是否为网络文件系统
+func (t *Table) isRemote(mountPath string) bool {
+    stack := t.byPath[mountPath]
+    return len(stack) > 0 && slices.Contains(remoteFSTypes, stack[len(stack)-1].FSType)
+}
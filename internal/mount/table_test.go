@@ -0,0 +1,73 @@
+package mount
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/moby/sys/mountinfo"
+)
+
+func TestTableLookup(t *testing.T) {
+    table := NewTable([]*mountinfo.Info{
+        {Mountpoint: "/mnt/smb/media", FSType: "tmpfs", Source: "tmpfs"},
+        {Mountpoint: "/mnt/smb/media", FSType: "cifs", Source: "//nas/media"},
+        {Mountpoint: "/mnt/smb/docs", FSType: "cifs", Source: "//nas/docs"},
+    })
+
+    if m := table.Lookup("/mnt/smb/media"); m == nil || m.Source != "//nas/media" {
+        t.Errorf("Lookup should return the topmost mount, got %+v", m)
+    }
+    if m := table.Lookup("/mnt/smb/docs/"); m == nil || m.Source != "//nas/docs" {
+        t.Errorf("Lookup should clean the path, got %+v", m)
+    }
+    if m := table.Lookup("/mnt/smb"); m != nil {
+        t.Errorf("Lookup should only match the mount point itself, got %+v", m)
+    }
+}
+
+func TestTableLookupSymlinkedParent(t *testing.T) {
+    dir := t.TempDir()
+    real := filepath.Join(dir, "real")
+    if err := os.Mkdir(real, 0755); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.Symlink(real, filepath.Join(dir, "link")); err != nil {
+        t.Fatal(err)
+    }
+
+    table := NewTable([]*mountinfo.Info{
+        {Mountpoint: filepath.Join(real, "share"), FSType: "cifs", Source: "//nas/share"},
+    })
+    if m := table.Lookup(filepath.Join(dir, "link", "share")); m == nil {
+        t.Error("Lookup should resolve symlinks in the parent directories")
+    }
+}
+
+// 父目录是 cifs 挂载点时不能解析其中的路径，失去响应的挂载上的 lstat 会阻塞
+// 这里用指向别处的符号链接代替 cifs 挂载点：被解析时会找不到嵌套的挂载
+func TestTableLookupStopsAtRemoteMount(t *testing.T) {
+    dir := t.TempDir()
+    if err := os.MkdirAll(filepath.Join(dir, "elsewhere", "sub"), 0755); err != nil {
+        t.Fatal(err)
+    }
+    projects := filepath.Join(dir, "projects")
+    if err := os.Symlink(filepath.Join(dir, "elsewhere"), projects); err != nil {
+        t.Fatal(err)
+    }
+
+    archive := filepath.Join(projects, "sub", "archive")
+    table := NewTable([]*mountinfo.Info{
+        {Mountpoint: projects, FSType: "cifs", Source: "//nas/projects"},
+        {Mountpoint: archive, FSType: "cifs", Source: "//nas/archive"},
+    })
+    if m := table.Lookup(archive); m == nil || m.Source != "//nas/archive" {
+        t.Errorf("Lookup(%s) = %+v, want //nas/archive", archive, m)
+    }
+
+    // Not mounted: the path below the cifs mount is used as is
+    missing := filepath.Join(projects, "sub", "other")
+    if got := table.resolve(missing); got != missing {
+        t.Errorf("resolve(%s) = %s, want the path unchanged", missing, got)
+    }
+}
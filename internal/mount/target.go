@@ -0,0 +1,143 @@
+package mount
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/moby/sys/mountinfo"
+)
+
+// systemTrees 是不允许在其中挂载的系统目录树
+var systemTrees = []string{"/usr", "/etc", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/boot", "/proc", "/sys", "/dev", "/run"}
+
+// userTrees 是系统目录树中按用户划分的目录，其中每个用户目录的子目录可以使用
+var userTrees = []string{"/run/media", "/run/user"}
+
+// protectedDirs 是不允许直接挂载在其上的目录，其子目录可以使用
+var protectedDirs = []string{"/", "/home", "/root", "/var", "/tmp", "/mnt", "/media", "/opt", "/srv"}
+
+// MountOptions 挂载选项
+type MountOptions struct {
+    ForceTarget bool // 跳过挂载目标的安全检查
+}
+
+// TargetError 表示挂载目标未通过安全检查
+type TargetError struct {
+    Path   string
+    Reason string
+}
+
+func (e *TargetError) Error() string {
+    return fmt.Sprintf("refusing to mount on %s: %s", e.Path, e.Reason)
+}
+
+// CheckTarget 检查挂载目标是否可以安全使用
+// 拒绝系统目录、解析到别处的符号链接、已挂载其他文件系统的挂载点以及非空目录，
+// 避免挂载隐藏已有内容；目标不存在时只检查路径本身
+func CheckTarget(entry *config.MountEntry) error {
+    path := entry.ActualMountPath
+    fail := func(format string, a ...any) error {
+        return &TargetError{Path: path, Reason: fmt.Sprintf(format, a...)}
+    }
+
+    if reason := systemPathReason(path); reason != "" {
+        return fail("%s", reason)
+    }
+
+    // A symlink would make the mount land somewhere else
+    if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+        resolved, err := filepath.EvalSymlinks(path)
+        if err != nil {
+            return fail("it is a symlink that cannot be resolved: %v", err)
+        }
+        if reason := systemPathReason(resolved); reason != "" {
+            return fail("it is a symlink to %s, %s", resolved, reason)
+        }
+        return fail("it is a symlink to %s", resolved)
+    }
+
+    info, err := os.Stat(path)
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return fail("%v", err)
+    }
+    if !info.IsDir() {
+        return fail("it is not a directory")
+    }
+
+    m, err := GetMountInfo(path)
+    if err != nil && !errors.Is(err, errNotMounted) {
+        return fmt.Errorf("failed to read mount table: %w", err)
+    }
+    if m != nil {
+        if !isSameShare(m, entry) {
+            return fail("it is already a mount point of %s (%s)", m.Source, m.FSType)
+        }
+        // Mounted with this share, reported as already mounted by the caller
+        return nil
+    }
+
+    entries, err := os.ReadDir(path)
+    if err != nil {
+        return fail("%v", err)
+    }
+    if len(entries) > 0 {
+        return fail("the directory is not empty (%d entries), its contents would be hidden", len(entries))
+    }
+    return nil
+}
+
+// systemPathReason 返回路径属于受保护的系统目录的原因，不属于时返回空字符串
+func systemPathReason(path string) string {
+    for _, dir := range protectedDirs {
+        if path == dir {
+            return "it is a system directory"
+        }
+    }
+    if filepath.Dir(path) == "/home" {
+        return "it is a home directory"
+    }
+    for _, tree := range userTrees {
+        if filepath.Dir(path) == tree {
+            return "it is a per-user directory"
+        }
+        if strings.HasPrefix(path, tree+"/") {
+            return ""
+        }
+    }
+    for _, tree := range systemTrees {
+        if path == tree || strings.HasPrefix(path, tree+"/") {
+            return fmt.Sprintf("it is inside the system directory %s", tree)
+        }
+    }
+    return ""
+}
+
+// isSameShare 检查挂载点的来源是否为条目的共享
+func isSameShare(m *mountinfo.Info, entry *config.MountEntry) bool {
+    if m.FSType != "cifs" && m.FSType != "smb3" {
+        return false
+    }
+    rest, ok := strings.CutPrefix(m.Source, "//")
+    if !ok {
+        return false
+    }
+    _, share, _ := strings.Cut(rest, "/")
+    if !strings.EqualFold(share, entry.ShareName) {
+        return false
+    }
+
+    host := sourceHost(m.Source)
+    for _, addr := range entry.Addresses() {
+        if strings.EqualFold(host, addr) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,27 @@
+package mount
+
+import "testing"
+
+func TestSystemPathReason(t *testing.T) {
+    tests := []struct {
+        path    string
+        refused bool
+    }{
+        {"/run", true},
+        {"/run/systemd/nas", true},
+        {"/run/media", true},
+        {"/run/media/alice", true},
+        {"/run/media/alice/nas", false},
+        {"/run/user/1000", true},
+        {"/run/user/1000/nas", false},
+        {"/usr/share/nas", true},
+        {"/home/alice", true},
+        {"/home/alice/nas", false},
+        {"/mnt/nas", false},
+    }
+    for _, tt := range tests {
+        if got := systemPathReason(tt.path) != ""; got != tt.refused {
+            t.Errorf("systemPathReason(%q) refused = %v, want %v", tt.path, got, tt.refused)
+        }
+    }
+}
@@ -1,58 +1,59 @@
 package mount
 
 import (
+    "context"
     "fmt"
-    "os"
     "os/exec"
+    "strings"
 )
 
 // BuildUmountCommand 为外部使用构建 umount 命令
-func BuildUmountCommand(mountPath string) *exec.Cmd {
-    return exec.Command("umount", mountPath)
+func BuildUmountCommand(mountPath string, opts UnmountOptions) *exec.Cmd {
+    var args []string
+    if opts.Lazy {
+        args = append(args, "-l")
+    }
+    if opts.Force {
+        args = append(args, "-f")
+    }
+    return exec.Command("umount", append(args, mountPath)...)
 }
 
-// Unmount 卸载已挂载的 SMB 共享
-func Unmount(mountPath string) error {
-    // Check if mounted
+// BuildRemountCommand 构建以新选项重新挂载的 mount 命令
+func BuildRemountCommand(mountPath string, options []string) *exec.Cmd {
+    return exec.Command("mount", "-o", strings.Join(append([]string{"remount"}, options...), ","), mountPath)
+}
+
+// Remount 以新的选项重新挂载已挂载的 SMB 共享
+func Remount(ctx context.Context, mountPath string, options []string) error {
     mounted, err := CheckStatus(mountPath)
     if err != nil {
-        return &MountError{Op: "umount", Path: mountPath, Err: fmt.Errorf("failed to check mount status: %w", err)}
+        return &MountError{Op: "remount", Path: mountPath, Err: fmt.Errorf("failed to check mount status: %w", err)}
     }
     if !mounted {
-        return &MountError{Op: "umount", Path: mountPath, Err: fmt.Errorf("not mounted")}
+        return &MountError{Op: "remount", Path: mountPath, Err: fmt.Errorf("not mounted")}
     }
 
-    // Build umount command
-    cmd := exec.Command("umount", mountPath)
-
-    // Execute umount command
-    output, err := cmd.CombinedOutput()
-    if err != nil {
-        return &MountError{Op: "umount", Path: mountPath, Err: fmt.Errorf("umount failed: %w\nOutput: %s", err, string(output))}
+    if err := Default().Remount(ctx, mountPath, options); err != nil {
+        return &MountError{Op: "remount", Path: mountPath, Err: err}
     }
-
     return nil
 }
 
-// CleanupMountPoint 如果挂载点目录为空则删除
-func CleanupMountPoint(mountPath string) error {
+// Unmount 卸载已挂载的 SMB 共享
+func Unmount(ctx context.Context, mountPath string, opts UnmountOptions) error {
     // Check if mounted
     mounted, err := CheckStatus(mountPath)
     if err != nil {
-        return err
-    }
-    if mounted {
-        return fmt.Errorf("cannot cleanup: still mounted")
+        return &MountError{Op: "umount", Path: mountPath, Err: fmt.Errorf("failed to check mount status: %w", err)}
     }
-
-    // Check if directory exists
-    if _, err := os.Stat(mountPath); os.IsNotExist(err) {
-        return nil // Already gone
+    if !mounted {
+        return &MountError{Op: "umount", Path: mountPath, Err: fmt.Errorf("not mounted")}
     }
 
-    // Remove the directory
-    if err := os.Remove(mountPath); err != nil {
-        return fmt.Errorf("failed to remove mount point: %w", err)
+    // Unmount using the configured backend
+    if err := Default().Unmount(ctx, mountPath, opts); err != nil {
+        return &MountError{Op: "umount", Path: mountPath, Err: err}
     }
 
     return nil
@@ -0,0 +1,212 @@
+package network
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net"
+    "strconv"
+    "strings"
+    "sync"
+    "syscall"
+    "time"
+)
+
+// ProbeStatus 是连通性探测的结果分类
+type ProbeStatus int
+
+const (
+    // Reachable 端口可以建立 TCP 连接
+    Reachable ProbeStatus = iota
+    // DNSFailure 主机名无法解析
+    DNSFailure
+    // HostUnreachable 主机没有响应或没有路由
+    HostUnreachable
+    // PortClosed 主机拒绝了连接，通常表示 SMB 服务未运行
+    PortClosed
+)
+
+// String 返回探测结果分类的描述
+func (s ProbeStatus) String() string {
+    switch s {
+    case Reachable:
+        return "reachable"
+    case DNSFailure:
+        return "DNS failure"
+    case HostUnreachable:
+        return "host unreachable"
+    case PortClosed:
+        return "port closed"
+    default:
+        return "unknown"
+    }
+}
+
+// ProbeResult 单次连通性探测的结果
+type ProbeResult struct {
+    Host    string
+    Port    int
+    Status  ProbeStatus
+    Latency time.Duration // 建立连接所用的时间，仅在 Reachable 时有意义
+    Timeout time.Duration // 探测使用的超时时间
+    Err     error         // 原始的拨号错误
+}
+
+// Address 返回探测的 host:port 地址
+func (r ProbeResult) Address() string {
+    return net.JoinHostPort(r.Host, strconv.Itoa(r.Port))
+}
+
+// Detail 返回探测失败的具体原因，成功时返回空字符串
+func (r ProbeResult) Detail() string {
+    switch r.Status {
+    case Reachable:
+        return ""
+    case DNSFailure:
+        return fmt.Sprintf("cannot resolve %s", r.Host)
+    case PortClosed:
+        return "connection refused"
+    }
+
+    var netErr net.Error
+    if errors.As(r.Err, &netErr) && netErr.Timeout() {
+        return fmt.Sprintf("no response within %s", r.Timeout)
+    }
+    return unwrapDialError(r.Err).Error()
+}
+
+// OK 返回探测是否成功
+func (r ProbeResult) OK() bool {
+    return r.Status == Reachable
+}
+
+// Error 探测失败时返回 *ProbeError，成功时返回 nil
+func (r ProbeResult) Error() error {
+    if r.OK() {
+        return nil
+    }
+    return &ProbeError{Result: r}
+}
+
+// ProbeError 描述失败的连通性探测
+// 主机不可达和端口关闭分别包装 EHOSTUNREACH 和 ECONNREFUSED，便于按临时错误重试
+type ProbeError struct {
+    Result ProbeResult
+}
+
+// Error 返回包含失败分类的错误信息
+func (e *ProbeError) Error() string {
+    r := e.Result
+    if r.Status == DNSFailure {
+        return fmt.Sprintf("%s: %s", r.Status, r.Detail())
+    }
+    return fmt.Sprintf("%s: %s: %s", r.Status, r.Address(), r.Detail())
+}
+
+// Unwrap 返回原始错误以及与分类对应的 syscall.Errno
+func (e *ProbeError) Unwrap() []error {
+    errs := []error{e.Result.Err}
+    switch e.Result.Status {
+    case HostUnreachable:
+        errs = append(errs, syscall.EHOSTUNREACH)
+    case PortClosed:
+        errs = append(errs, syscall.ECONNREFUSED)
+    }
+    return errs
+}
+
+// Probe 尝试在超时时间内与 host:port 建立 TCP 连接，连接成功后立即关闭
+func Probe(ctx context.Context, host string, port int, timeout time.Duration) ProbeResult {
+    result := ProbeResult{Host: host, Port: port, Timeout: timeout}
+
+    ctx, cancel := context.WithTimeout(ctx, timeout)
+    defer cancel()
+
+    var dialer net.Dialer
+    start := time.Now()
+    conn, err := dialer.DialContext(ctx, "tcp", result.Address())
+    if err != nil {
+        result.Err = err
+        result.Status = classifyDialError(err)
+        return result
+    }
+    result.Latency = time.Since(start)
+    conn.Close()
+
+    result.Status = Reachable
+    return result
+}
+
+// classifyDialError 将拨号错误归类为 DNS 失败、端口关闭或主机不可达
+func classifyDialError(err error) ProbeStatus {
+    var dnsErr *net.DNSError
+    switch {
+    case errors.As(err, &dnsErr):
+        return DNSFailure
+    case errors.Is(err, syscall.ECONNREFUSED):
+        return PortClosed
+    default:
+        return HostUnreachable
+    }
+}
+
+// unwrapDialError 去掉 net.OpError 的 "dial tcp" 前缀，只保留底层原因
+func unwrapDialError(err error) error {
+    var opErr *net.OpError
+    if errors.As(err, &opErr) && opErr.Err != nil {
+        return opErr.Err
+    }
+    return err
+}
+
+// ProbeAll 并发探测多个地址，结果按 hosts 的顺序排列
+func ProbeAll(ctx context.Context, hosts []string, port int, timeout time.Duration) []ProbeResult {
+    results := make([]ProbeResult, len(hosts))
+    var wg sync.WaitGroup
+    for i, host := range hosts {
+        wg.Add(1)
+        go func(i int, host string) {
+            defer wg.Done()
+            results[i] = Probe(ctx, host, port, timeout)
+        }(i, host)
+    }
+    wg.Wait()
+    return results
+}
+
+// FirstReachable 返回第一个可达的结果
+// 只有一个地址时直接返回其 *ProbeError，多个地址都不可达时返回 *UnreachableError
+func FirstReachable(results []ProbeResult) (ProbeResult, error) {
+    for _, r := range results {
+        if r.OK() {
+            return r, nil
+        }
+    }
+    if len(results) == 1 {
+        return ProbeResult{}, results[0].Error()
+    }
+    return ProbeResult{}, &UnreachableError{Results: results}
+}
+
+// UnreachableError 描述所有地址都不可达的情况
+type UnreachableError struct {
+    Results []ProbeResult
+}
+
+// Error 返回每个地址的失败原因
+func (e *UnreachableError) Error() string {
+    parts := make([]string, len(e.Results))
+    for i, r := range e.Results {
+        parts[i] = r.Error().Error()
+    }
+    return "no address reachable: " + strings.Join(parts, "; ")
+}
+
+// Unwrap 返回每个地址的 *ProbeError，任一地址的临时错误都可以触发重试
+func (e *UnreachableError) Unwrap() []error {
+    errs := make([]error, len(e.Results))
+    for i, r := range e.Results {
+        errs[i] = r.Error()
+    }
+    return errs
+}
@@ -0,0 +1,69 @@
+package network
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "net"
+    "strconv"
+    "time"
+)
+
+// wakePollInterval 是等待服务器启动时两次探测之间的间隔
+const wakePollInterval = 2 * time.Second
+
+// MagicPacket 构建 Wake-on-LAN 唤醒包：6 个 0xFF 后接 16 次目标 MAC 地址
+func MagicPacket(mac string) ([]byte, error) {
+    hw, err := net.ParseMAC(mac)
+    if err != nil {
+        return nil, fmt.Errorf("invalid MAC address %q: %w", mac, err)
+    }
+    if len(hw) != 6 {
+        return nil, fmt.Errorf("invalid MAC address %q: Wake-on-LAN requires a 6-byte address", mac)
+    }
+
+    packet := bytes.Repeat([]byte{0xFF}, 6)
+    for range 16 {
+        packet = append(packet, hw...)
+    }
+    return packet, nil
+}
+
+// SendMagicPacket 通过 UDP 向广播地址发送唤醒包
+func SendMagicPacket(mac, broadcast string, port int) error {
+    packet, err := MagicPacket(mac)
+    if err != nil {
+        return err
+    }
+
+    conn, err := net.Dial("udp", net.JoinHostPort(broadcast, strconv.Itoa(port)))
+    if err != nil {
+        return fmt.Errorf("failed to open UDP socket: %w", err)
+    }
+    defer conn.Close()
+
+    if _, err := conn.Write(packet); err != nil {
+        return fmt.Errorf("failed to send magic packet: %w", err)
+    }
+    return nil
+}
+
+// WaitReachable 反复探测 hosts 直到有地址可达或 wait 时间耗尽
+// 返回第一个可达的结果；超时后返回最后一轮探测的错误
+func WaitReachable(ctx context.Context, hosts []string, port int, probeTimeout, wait time.Duration) (ProbeResult, error) {
+    ctx, cancel := context.WithTimeout(ctx, wait)
+    defer cancel()
+
+    for {
+        result, err := FirstReachable(ProbeAll(ctx, hosts, port, probeTimeout))
+        if err == nil {
+            return result, nil
+        }
+
+        select {
+        case <-time.After(wakePollInterval):
+        case <-ctx.Done():
+            return ProbeResult{}, err
+        }
+    }
+}
@@ -0,0 +1,251 @@
+package state
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+
+    "golang.org/x/sys/unix"
+)
+
+// fileVersion 是状态文件的格式版本
+const fileVersion = 1
+
+// State 记录 smb_mount 在多次运行之间需要保留的运行时信息，按挂载路径索引
+type State struct {
+    Version int               `json:"version"`
+    Mounts  map[string]*Mount `json:"mounts"`
+}
+
+// Mount 单个挂载点的运行时信息
+type Mount struct {
+    Name        string   `json:"name"`
+    Tunnel      *Tunnel  `json:"tunnel,omitempty"`
+    CreatedDirs []string `json:"created_dirs,omitempty"` // smb_mount 为挂载点创建的目录，由深到浅
+    Expiry      *Expiry  `json:"expiry,omitempty"`
+}
+
+// Tunnel 为挂载启动的 SSH 隧道
+type Tunnel struct {
+    PID       int `json:"pid"`        // 隧道监督进程的 PID
+    LocalPort int `json:"local_port"` // 转发到服务器 SMB 端口的本地端口
+}
+
+// Expiry 限时挂载的到期信息
+type Expiry struct {
+    At  time.Time `json:"at"`  // 到期时间
+    PID int       `json:"pid"` // 到期时执行卸载的后台进程的 PID
+}
+
+// empty 返回记录是否不再包含任何信息
+func (m *Mount) empty() bool {
+    return m.Tunnel == nil && len(m.CreatedDirs) == 0 && m.Expiry == nil
+}
+
+// Get 返回挂载路径的记录，不存在时返回 nil
+func (s *State) Get(path string) *Mount {
+    return s.Mounts[path]
+}
+
+// Ensure 返回挂载路径的记录，不存在时创建
+func (s *State) Ensure(path, name string) *Mount {
+    m := s.Mounts[path]
+    if m == nil {
+        m = &Mount{}
+        s.Mounts[path] = m
+    }
+    m.Name = name
+    return m
+}
+
+// Prune 删除不再包含任何信息的记录
+func (s *State) Prune() {
+    for path, m := range s.Mounts {
+        if m.empty() {
+            delete(s.Mounts, path)
+        }
+    }
+}
+
+// Path 返回状态文件路径：$XDG_STATE_HOME/smb_mount/state.json，默认为 ~/.local/state/smb_mount/state.json
+func Path() string {
+    dir := os.Getenv("XDG_STATE_HOME")
+    if dir == "" {
+        home, err := os.UserHomeDir()
+        if err != nil {
+            return ""
+        }
+        dir = filepath.Join(home, ".local", "state")
+    }
+    return filepath.Join(dir, "smb_mount", "state.json")
+}
+
+// Dir 返回状态文件所在的目录
+func Dir() string {
+    return filepath.Dir(Path())
+}
+
+// 状态目录中新建的目录和文件的属主，为 -1 时不修改，参见 SetOwner
+var ownerUID, ownerGID = -1, -1
+
+// SetOwner 设置状态目录中新建的目录和文件的属主
+// 以 root 身份替其他用户运行时（例如经 sudo 运行的私有会话）使用，避免在用户的状态目录中留下 root 的文件
+func SetOwner(uid, gid int) {
+    ownerUID, ownerGID = uid, gid
+}
+
+// chown 将路径交给 SetOwner 设置的属主，未设置时不处理
+func chown(path string) error {
+    if ownerUID < 0 {
+        return nil
+    }
+    if err := os.Lchown(path, ownerUID, ownerGID); err != nil {
+        return fmt.Errorf("failed to change owner of %s: %w", path, err)
+    }
+    return nil
+}
+
+// ensureDir 创建状态目录及其缺失的父目录，新建的目录交给属主
+func ensureDir() error {
+    var missing []string
+    for dir := Dir(); ; dir = filepath.Dir(dir) {
+        if _, err := os.Stat(dir); err == nil || !errors.Is(err, os.ErrNotExist) {
+            break
+        }
+        missing = append(missing, dir)
+        if parent := filepath.Dir(dir); parent == dir {
+            break
+        }
+    }
+
+    if err := os.MkdirAll(Dir(), 0700); err != nil {
+        return fmt.Errorf("failed to create state directory: %w", err)
+    }
+    for _, dir := range missing {
+        if err := chown(dir); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// OpenLog 打开状态目录中的日志文件，不存在时创建
+// flag 是额外的打开标志，例如 os.O_TRUNC 或 os.O_APPEND
+func OpenLog(name string, flag int) (*os.File, error) {
+    if Path() == "" {
+        return nil, fmt.Errorf("cannot determine state file path")
+    }
+    if err := ensureDir(); err != nil {
+        return nil, err
+    }
+    f, err := os.OpenFile(filepath.Join(Dir(), name), os.O_WRONLY|os.O_CREATE|flag, 0600)
+    if err != nil {
+        return nil, err
+    }
+    if err := chown(f.Name()); err != nil {
+        f.Close()
+        return nil, err
+    }
+    return f, nil
+}
+
+// Load 读取状态文件，文件不存在时返回空状态
+func Load() (*State, error) {
+    var s *State
+    err := withLock(unix.LOCK_SH, func() error {
+        var err error
+        s, err = read()
+        return err
+    })
+    return s, err
+}
+
+// Update 在排他锁内读取状态、调用 fn 修改并写回
+// fn 返回错误时不写回
+func Update(fn func(s *State) error) error {
+    return withLock(unix.LOCK_EX, func() error {
+        s, err := read()
+        if err != nil {
+            return err
+        }
+        if err := fn(s); err != nil {
+            return err
+        }
+        s.Prune()
+        return write(s)
+    })
+}
+
+// withLock 持有状态目录中锁文件的 flock 执行 fn，多个 smb_mount 进程之间互斥
+func withLock(how int, fn func() error) error {
+    path := Path()
+    if path == "" {
+        return fmt.Errorf("cannot determine state file path")
+    }
+    if err := ensureDir(); err != nil {
+        return err
+    }
+
+    lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+    if err != nil {
+        return fmt.Errorf("failed to open state lock: %w", err)
+    }
+    defer lock.Close()
+    if err := chown(lock.Name()); err != nil {
+        return err
+    }
+
+    if err := unix.Flock(int(lock.Fd()), how); err != nil {
+        return fmt.Errorf("failed to lock state file: %w", err)
+    }
+    defer unix.Flock(int(lock.Fd()), unix.LOCK_UN)
+
+    return fn()
+}
+
+// read 读取并解析状态文件
+func read() (*State, error) {
+    s := &State{Version: fileVersion, Mounts: make(map[string]*Mount)}
+
+    data, err := os.ReadFile(Path())
+    if errors.Is(err, os.ErrNotExist) {
+        return s, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read state file: %w", err)
+    }
+
+    if err := json.Unmarshal(data, s); err != nil {
+        return nil, fmt.Errorf("failed to parse state file %s: %w", Path(), err)
+    }
+    if s.Mounts == nil {
+        s.Mounts = make(map[string]*Mount)
+    }
+    return s, nil
+}
+
+// write 先写入临时文件再重命名，避免中断时留下损坏的状态文件
+func write(s *State) error {
+    data, err := json.MarshalIndent(s, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to encode state: %w", err)
+    }
+
+    path := Path()
+    tmp := path + ".tmp"
+    if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+        return fmt.Errorf("failed to write state file: %w", err)
+    }
+    if err := chown(tmp); err != nil {
+        os.Remove(tmp)
+        return err
+    }
+    if err := os.Rename(tmp, path); err != nil {
+        os.Remove(tmp)
+        return fmt.Errorf("failed to write state file: %w", err)
+    }
+    return nil
+}
@@ -3,6 +3,7 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/hsldymq/smb_mount/internal/config"
@@ -175,14 +176,20 @@ func (m ListModel) renderRows() string {
 
 // renderRow renders a single row
 func (m ListModel) renderRow(index int, entry config.MountEntry) string {
+	// Time-limited mounts show how long until they are unmounted
+	var remaining string
+	if entry.IsMounted && !entry.ExpiresAt.IsZero() {
+		remaining = formatExpiry(&entry, time.Now())
+	}
+
 	// Calculate column widths
 	nameWidth := 20
 	addrWidth := 20
-	pathWidth := max(m.Width-nameWidth-addrWidth-20, 30)
+	pathWidth := max(m.Width-nameWidth-addrWidth-20-len(remaining), 30)
 
 	// Truncate values if too long
 	name := truncate(entry.Name, nameWidth)
-	addr := truncate(fmt.Sprintf("%s:%d", entry.SMBAddr, entry.GetSMBPort()), addrWidth)
+	addr := truncate(fmt.Sprintf("%s:%d", entry.GetSMBAddr(), entry.GetSMBPort()), addrWidth)
 	path := truncate(entry.ActualMountPath, pathWidth)
 
 	// Build row
@@ -190,24 +197,38 @@ func (m ListModel) renderRow(index int, entry config.MountEntry) string {
 		nameWidth, name,
 		addrWidth, addr,
 		pathWidth, path,
-		RenderStatusBadge(entry.IsMounted),
+		RenderStatusBadge(&entry),
 	)
+	if remaining != "" {
+		row += " " + DimStyle.Render(remaining)
+	}
 
 	return row
 }
 
 // renderSummary renders a summary of mount status
 func (m ListModel) renderSummary() string {
-	mounted := 0
+	mounted, stale, foreign := 0, 0, 0
 	for _, m := range m.Mounts {
 		if m.IsMounted {
 			mounted++
+			if m.IsStale {
+				stale++
+			}
+		} else if m.ForeignMount != "" {
+			foreign++
 		}
 	}
 
 	total := len(m.Mounts)
 	summary := fmt.Sprintf("Total: %d | Mounted: %d | Unmounted: %d",
-		total, mounted, total-mounted)
+		total, mounted, total-mounted-foreign)
+	if stale > 0 {
+		summary += fmt.Sprintf(" | Stale: %d", stale)
+	}
+	if foreign > 0 {
+		summary += fmt.Sprintf(" | Foreign: %d", foreign)
+	}
 
 	return SubtitleStyle.Render(summary)
 }
@@ -247,3 +268,29 @@ func DisplayList(mounts []config.MountEntry) error {
 	_ = finalModel // Model is discarded, just displaying info
 	return nil
 }
+
+// formatExpiry describes when a time-limited mount is unmounted
+// Past the expiry time, or when the expiry process is gone, the share will not be unmounted on its own
+func formatExpiry(entry *config.MountEntry, now time.Time) string {
+	d := entry.ExpiresAt.Sub(now)
+	switch {
+	case d <= 0:
+		return "unmount overdue"
+	case entry.ExpiryStopped:
+		return "auto unmount stopped"
+	default:
+		return formatRemaining(d) + " left"
+	}
+}
+
+// formatRemaining formats the time left until expiry, e.g. 1h25m
+func formatRemaining(d time.Duration) string {
+	if d < time.Minute {
+		return "<1m"
+	}
+	d = d.Round(time.Minute)
+	if h := int(d.Hours()); h > 0 {
+		return fmt.Sprintf("%dh%02dm", h, int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dm", int(d.Minutes()))
+}
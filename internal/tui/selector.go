@@ -202,13 +202,22 @@ func (m SelectorModel) renderItem(index int, entry config.MountEntry) string {
 
 	// SMB 地址
 	parts = append(parts, fmt.Sprintf("(%s:%d/%s)",
-		entry.SMBAddr, entry.GetSMBPort(), entry.ShareName))
+		entry.GetSMBAddr(), entry.GetSMBPort(), entry.ShareName))
+
+	// 标签
+	for _, tag := range entry.Tags {
+		parts = append(parts, DimStyle.Render(config.TagPrefix+tag))
+	}
 
 	// 状态（如果启用）
 	if m.ShowStatus {
 		status := "Unmounted"
-		if entry.IsMounted {
+		if entry.IsMounted && entry.IsStale {
+			status = "Stale, not responding"
+		} else if entry.IsMounted {
 			status = "Mounted"
+		} else if entry.ForeignMount != "" {
+			status = "Foreign mount: " + entry.ForeignMount
 		}
 		parts = append(parts, fmt.Sprintf("[%s]", status))
 	}
@@ -2,6 +2,7 @@ package tui
 
 import (
     "github.com/charmbracelet/lipgloss"
+    "github.com/hsldymq/smb_mount/internal/config"
 )
 
 // Color palette
@@ -103,6 +104,18 @@ var (
         Padding(0, 1).
         Background(lipgloss.Color("236"))
 
+    StatusBadgeStale = lipgloss.NewStyle().
+        Foreground(errorColor).
+        Bold(true).
+        Padding(0, 1).
+        Background(lipgloss.Color("236"))
+
+    StatusBadgeForeign = lipgloss.NewStyle().
+        Foreground(warningColor).
+        Bold(true).
+        Padding(0, 1).
+        Background(lipgloss.Color("236"))
+
     StatusBadgeUnmounted = lipgloss.NewStyle().
         Foreground(dimColor).
         Bold(true).
@@ -135,10 +148,18 @@ var (
         Italic(true)
 )
 
-// RenderStatusBadge returns a styled status badge
-func RenderStatusBadge(isMounted bool) string {
-    if isMounted {
+// RenderStatusBadge returns a styled status badge for the entry
+// STALE marks a mounted share that does not respond, FOREIGN a mount point that
+// holds a different filesystem than the configured share
+func RenderStatusBadge(entry *config.MountEntry) string {
+    if entry.IsMounted && entry.IsStale {
+        return StatusBadgeStale.Render("STALE")
+    }
+    if entry.IsMounted {
         return StatusBadgeMounted.Render("MOUNTED")
     }
+    if entry.ForeignMount != "" {
+        return StatusBadgeForeign.Render("FOREIGN")
+    }
     return StatusBadgeUnmounted.Render("UNMOUNTED")
 }
@@ -0,0 +1,278 @@
+package tunnel
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "net"
+    "os"
+    "os/exec"
+    "os/signal"
+    "strconv"
+    "strings"
+    "syscall"
+    "time"
+
+    "github.com/hsldymq/smb_mount/internal/config"
+    "github.com/hsldymq/smb_mount/internal/network"
+    "github.com/hsldymq/smb_mount/internal/state"
+)
+
+// SuperviseCommand 是运行隧道监督进程的隐藏子命令
+const SuperviseCommand = "__tunnel"
+
+const (
+    // readyPollInterval 是等待本地端口可用时两次探测之间的间隔
+    readyPollInterval = 200 * time.Millisecond
+    // stopTimeout 是停止监督进程时等待其退出的时间，超时后强制结束
+    stopTimeout = 5 * time.Second
+    // maxRestartDelay 是 ssh 退出后重新启动的最长等待时间
+    maxRestartDelay = 30 * time.Second
+    // logTailLines 是隧道启动失败时错误中包含的日志行数
+    logTailLines = 5
+)
+
+// ReadySignal 是 Start 在本地端口第一次可以连接后发给监督进程的信号
+// 收到之前 ssh 退出说明是永久错误（例如认证失败或未知的主机密钥），监督进程直接退出而不重启
+const ReadySignal = syscall.SIGUSR1
+
+// Tunnel 运行中的 SSH 隧道
+type Tunnel struct {
+    PID       int // 监督进程的 PID
+    LocalPort int
+    LogPath   string
+}
+
+// Start 启动转发 entry 的 SMB 端口的 SSH 隧道，并等待本地端口可以连接
+// 隧道由独立会话中的监督进程运行，smb_mount 退出后仍然保持，ssh 断开时自动重连
+// 本地端口可以连接之前 ssh 就退出时立即返回错误，错误中包含隧道日志的最后几行
+func Start(ctx context.Context, entry *config.MountEntry) (*Tunnel, error) {
+    port, err := freePort()
+    if err != nil {
+        return nil, err
+    }
+
+    exe, err := os.Executable()
+    if err != nil {
+        return nil, fmt.Errorf("failed to locate smb_mount executable: %w", err)
+    }
+
+    logFile, err := state.OpenLog("tunnel-"+strings.ReplaceAll(entry.Name, "/", "_")+".log", os.O_TRUNC)
+    if err != nil {
+        return nil, fmt.Errorf("failed to open tunnel log: %w", err)
+    }
+    defer logFile.Close()
+    logPath := logFile.Name()
+
+    args := append([]string{SuperviseCommand, "ssh"}, sshArgs(entry, port)...)
+    cmd := exec.Command(exe, args...)
+    cmd.Stdout = logFile
+    cmd.Stderr = logFile
+    cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+    if err := cmd.Start(); err != nil {
+        return nil, fmt.Errorf("failed to start tunnel: %w", err)
+    }
+
+    t := &Tunnel{PID: cmd.Process.Pid, LocalPort: port, LogPath: logPath}
+    exited := make(chan struct{})
+    go func() {
+        _ = cmd.Wait()
+        close(exited)
+    }()
+
+    // Wait until ssh has authenticated and is listening on the local port
+    for {
+        if network.Probe(ctx, "127.0.0.1", port, time.Second).OK() {
+            // From now on the supervisor restarts ssh when it exits
+            if err := syscall.Kill(t.PID, ReadySignal); err != nil {
+                return nil, fmt.Errorf("tunnel exited: %w", err)
+            }
+            return t, nil
+        }
+
+        select {
+        case <-time.After(readyPollInterval):
+        case <-exited:
+            return nil, fmt.Errorf("tunnel failed: ssh exited before the tunnel was ready\nOutput: %s", tail(logPath, logTailLines))
+        case <-ctx.Done():
+            _ = Stop(t.PID)
+            if lines := tail(logPath, logTailLines); lines != "" {
+                return nil, fmt.Errorf("tunnel not ready: %w\nOutput: %s", ctx.Err(), lines)
+            }
+            return nil, fmt.Errorf("tunnel not ready: %w", ctx.Err())
+        }
+    }
+}
+
+// sshArgs 构建 ssh 端口转发的参数
+// SMB 地址由跳板机解析，因此转发目标使用配置中的原始服务器名
+func sshArgs(entry *config.MountEntry, localPort int) []string {
+    t := entry.Tunnel
+    target := net.JoinHostPort(entry.SMBAddr, strconv.Itoa(entry.GetSMBPort()))
+
+    args := []string{
+        "-N",
+        "-o", "ExitOnForwardFailure=yes",
+        "-o", "BatchMode=yes",
+        "-o", "ServerAliveInterval=15",
+        "-o", "ServerAliveCountMax=3",
+        "-L", fmt.Sprintf("127.0.0.1:%d:%s", localPort, target),
+    }
+    if t.SSHPort != 0 {
+        args = append(args, "-p", strconv.Itoa(t.SSHPort))
+    }
+    if t.IdentityFile != "" {
+        args = append(args, "-i", t.IdentityFile, "-o", "IdentitiesOnly=yes")
+    }
+
+    dest := t.SSHHost
+    if t.SSHUser != "" {
+        dest = t.SSHUser + "@" + dest
+    }
+    return append(args, dest)
+}
+
+// Supervise 运行 command 并在其退出后重新启动，直到 ctx 结束
+// 重启间隔从 1 秒开始翻倍，最长 maxRestartDelay；运行超过一分钟后重置
+// 收到 ReadySignal 之前 command 退出时不重启，直接返回错误
+func Supervise(ctx context.Context, command []string) error {
+    if len(command) == 0 {
+        return fmt.Errorf("no command to supervise")
+    }
+
+    readySig := make(chan os.Signal, 1)
+    signal.Notify(readySig, ReadySignal)
+    defer signal.Stop(readySig)
+    ready := false
+
+    delay := time.Second
+    for {
+        cmd := exec.Command(command[0], command[1:]...)
+        cmd.Stdout = os.Stdout
+        cmd.Stderr = os.Stderr
+
+        started := time.Now()
+        if err := cmd.Start(); err != nil {
+            return fmt.Errorf("failed to start %s: %w", command[0], err)
+        }
+
+        done := make(chan error, 1)
+        go func() {
+            done <- cmd.Wait()
+        }()
+
+        var err error
+    wait:
+        for {
+            select {
+            case err = <-done:
+                break wait
+            case <-readySig:
+                ready = true
+            case <-ctx.Done():
+                _ = cmd.Process.Signal(syscall.SIGTERM)
+                <-done
+                return nil
+            }
+        }
+
+        // The ready signal may arrive together with the exit
+        select {
+        case <-readySig:
+            ready = true
+        default:
+        }
+        if !ready {
+            return fmt.Errorf("%s exited before the tunnel was ready (%v), not restarting", command[0], err)
+        }
+
+        if time.Since(started) > time.Minute {
+            delay = time.Second
+        }
+        fmt.Fprintf(os.Stderr, "%s: %s exited (%v), restarting in %s\n",
+            time.Now().Format(time.RFC3339), command[0], err, delay)
+
+        select {
+        case <-time.After(delay):
+        case <-ctx.Done():
+            return nil
+        }
+        delay = min(delay*2, maxRestartDelay)
+    }
+}
+
+// Stop 停止隧道监督进程，进程已不存在时返回 nil
+// 只会向 smb_mount 的监督进程发送信号，PID 被其他进程重用时不做任何操作
+func Stop(pid int) error {
+    if !Running(pid) {
+        return nil
+    }
+
+    if err := syscall.Kill(pid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+        return fmt.Errorf("failed to stop tunnel (pid %d): %w", pid, err)
+    }
+
+    deadline := time.Now().Add(stopTimeout)
+    for time.Now().Before(deadline) {
+        if !Running(pid) {
+            return nil
+        }
+        time.Sleep(100 * time.Millisecond)
+    }
+
+    if err := syscall.Kill(pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+        return fmt.Errorf("failed to kill tunnel (pid %d): %w", pid, err)
+    }
+    return nil
+}
+
+// Running 返回 pid 是否为正在运行的隧道监督进程
+func Running(pid int) bool {
+    if pid <= 0 {
+        return false
+    }
+    cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+    if err != nil {
+        return false
+    }
+    args := bytes.Split(cmdline, []byte{0})
+    if len(args) < 2 || string(args[1]) != SuperviseCommand {
+        return false
+    }
+
+    // A zombie keeps its cmdline until reaped, check the process state too
+    stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+    if err != nil {
+        return false
+    }
+    if i := bytes.LastIndexByte(stat, ')'); i >= 0 && i+2 < len(stat) && stat[i+2] == 'Z' {
+        return false
+    }
+    return true
+}
+
+// freePort 返回一个当前未被占用的本地 TCP 端口
+func freePort() (int, error) {
+    l, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        return 0, fmt.Errorf("failed to find a free local port: %w", err)
+    }
+    defer l.Close()
+    return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// tail 返回日志文件最后 n 行非空内容
+func tail(path string, n int) string {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return ""
+    }
+    var lines []string
+    for _, line := range strings.Split(string(data), "\n") {
+        if line = strings.TrimSpace(line); line != "" {
+            lines = append(lines, line)
+        }
+    }
+    return strings.Join(lines[max(len(lines)-n, 0):], "\n")
+}